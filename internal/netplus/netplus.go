@@ -6,9 +6,14 @@
 package netplus
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
+	"time"
 )
 
 var ErrorURLParsing = errors.New("error parsing URL")
@@ -26,3 +31,47 @@ func PaseURL(anURL, user, pass string) (*url.URL, error) {
 
 	return u, nil
 }
+
+// PeerCertificates returns the certificate chain presented by a TLS server at address.
+// The chain is returned even if the handshake fails afterwards, like when the server
+// requires a client certificate.
+func PeerCertificates(
+	ctx context.Context,
+	address string,
+	timeout time.Duration,
+	config *tls.Config,
+) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	cfg := config.Clone()
+	cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+		return nil
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    cfg,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err == nil {
+		conn.Close()
+	}
+	if len(certs) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no certificate presented by %s", address)
+		}
+		return nil, err
+	}
+	return certs, nil
+}
+
+// DaysUntil returns the number of whole days from t until date
+func DaysUntil(date, t time.Time) int64 {
+	return int64(date.Sub(t).Hours() / 24)
+}
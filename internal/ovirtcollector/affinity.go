@@ -0,0 +1,334 @@
+// This file contains ovirtcollector methods to gathers stats about affinity groups
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// affinityRule contains the settings of an affinity group rule
+type affinityRule struct {
+	enabled, positive, enforcing bool
+}
+
+// affinityViolation contains the VMs breaking an affinity group rule
+type affinityViolation struct {
+	rule    string
+	vmnames []string
+}
+
+// CollectAffinityGroupsInfo gathers oVirt affinity groups info and checks their rules
+// against VMs placement
+func (c *OVirtCollector) CollectAffinityGroupsInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		groups               *ovirtsdk.AffinityGroupSlice
+		agtags               = make(map[string]string)
+		agfields             = make(map[string]interface{})
+		placement            map[string]string
+		vmids, hoids         []string
+		vmsrule, hostsrule   affinityRule
+		violations           []affinityViolation
+		clid, clname, dcname string
+		id, name             string
+		t                    time.Time
+		priority             float64
+		running              int
+		ok                   bool
+		err                  error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get affinity groups info: %w", ErrorNoClient)
+	}
+
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return fmt.Errorf("could not get all datacenter entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	t = time.Now()
+	placement = c.vmsPlacement()
+
+	for _, cl := range c.clusters.Slice() {
+		if clid, ok = cl.Id(); !ok {
+			acc.AddError(errors.New("found a cluster without Id, skipping"))
+			continue
+		}
+		if clname, ok = cl.Name(); !ok {
+			acc.AddError(errors.New("found a cluster without Name, skipping"))
+			continue
+		}
+		if !c.filterClusters.Match(clname) {
+			continue
+		}
+		if groups, err = c.clusterAffinityGroups(clid); err != nil {
+			acc.AddError(
+				fmt.Errorf("could not get affinity groups for cluster %s: %w", clname, err),
+			)
+			continue
+		}
+		dcname = c.clusterDatacenterName(cl)
+		for _, ag := range groups.Slice() {
+			if id, ok = ag.Id(); !ok {
+				acc.AddError(errors.New("found an affinity group without Id, skipping"))
+				continue
+			}
+			name, _ = ag.Name()
+			priority, _ = ag.Priority()
+			vmsrule, hostsrule = affinityGroupRules(ag)
+			vmids, hoids = affinityGroupMembers(ag)
+			running = 0
+			for _, vmid := range vmids {
+				if placement[vmid] != "" {
+					running++
+				}
+			}
+			violations = c.affinityViolations(vmids, hoids, vmsrule, hostsrule, placement)
+
+			agtags["clustername"] = clname
+			agtags["dcname"] = dcname
+			agtags["id"] = id
+			agtags["name"] = name
+			agtags["ovirt-engine"] = c.url.Host
+
+			agfields["hosts"] = len(hoids)
+			agfields["hosts_rule_enabled"] = hostsrule.enabled
+			agfields["hosts_rule_enforcing"] = hostsrule.enforcing
+			agfields["hosts_rule_positive"] = hostsrule.positive
+			agfields["priority"] = priority
+			agfields["violations"] = len(violations)
+			agfields["vms"] = len(vmids)
+			agfields["vms_running"] = running
+			agfields["vms_rule_enabled"] = vmsrule.enabled
+			agfields["vms_rule_enforcing"] = vmsrule.enforcing
+			agfields["vms_rule_positive"] = vmsrule.positive
+
+			acc.AddFields("ovirtstat_affinity_group", agfields, agtags, t)
+
+			for _, v := range violations {
+				c.addAffinityViolation(acc, agtags, v, vmsrule, hostsrule, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAffinityViolation adds an ovirtstat_affinity_violation metric to the accumulator
+func (c *OVirtCollector) addAffinityViolation(
+	acc *metric.Accumulator,
+	agtags map[string]string,
+	v affinityViolation,
+	vmsrule, hostsrule affinityRule,
+	t time.Time,
+) {
+	var rule affinityRule
+
+	rule = vmsrule
+	if v.rule == "hosts" {
+		rule = hostsrule
+	}
+	acc.AddFields(
+		"ovirtstat_affinity_violation",
+		map[string]interface{}{
+			"enforcing": rule.enforcing,
+			"vmnames":   strings.Join(v.vmnames, ","),
+			"vms":       len(v.vmnames),
+		},
+		map[string]string{
+			"clustername":  agtags["clustername"],
+			"dcname":       agtags["dcname"],
+			"id":           agtags["id"],
+			"name":         agtags["name"],
+			"ovirt-engine": c.url.Host,
+			"positive":     fmt.Sprintf("%t", rule.positive),
+			"rule":         v.rule,
+		},
+		t,
+	)
+}
+
+// affinityViolations returns the rules of an affinity group broken by the current VMs
+// placement. Only running VMs are taken into account.
+func (c *OVirtCollector) affinityViolations(
+	vmids, hoids []string,
+	vmsrule, hostsrule affinityRule,
+	placement map[string]string,
+) []affinityViolation {
+	var (
+		violations []affinityViolation
+		perhost    = make(map[string][]string)
+		inhosts    = make(map[string]bool)
+		vmnames    []string
+		hoid       string
+	)
+
+	for _, id := range hoids {
+		inhosts[id] = true
+	}
+	for _, vmid := range vmids {
+		if hoid = placement[vmid]; hoid != "" {
+			perhost[hoid] = append(perhost[hoid], c.vmNameFromID(vmid))
+		}
+	}
+
+	if vmsrule.enabled {
+		vmnames = nil
+		if vmsrule.positive && len(perhost) > 1 {
+			for _, names := range perhost {
+				vmnames = append(vmnames, names...)
+			}
+		}
+		if !vmsrule.positive {
+			for _, names := range perhost {
+				if len(names) > 1 {
+					vmnames = append(vmnames, names...)
+				}
+			}
+		}
+		if len(vmnames) > 0 {
+			sort.Strings(vmnames)
+			violations = append(violations, affinityViolation{rule: "vms", vmnames: vmnames})
+		}
+	}
+
+	if hostsrule.enabled && len(hoids) > 0 {
+		vmnames = nil
+		for hoid, names := range perhost {
+			if inhosts[hoid] != hostsrule.positive {
+				vmnames = append(vmnames, names...)
+			}
+		}
+		if len(vmnames) > 0 {
+			sort.Strings(vmnames)
+			violations = append(violations, affinityViolation{rule: "hosts", vmnames: vmnames})
+		}
+	}
+
+	return violations
+}
+
+// clusterAffinityGroups returns the affinity groups of a cluster
+func (c *OVirtCollector) clusterAffinityGroups(id string) (*ovirtsdk.AffinityGroupSlice, error) {
+	var (
+		resp   *ovirtsdk.AffinityGroupsServiceListResponse
+		groups *ovirtsdk.AffinityGroupSlice
+		ok     bool
+		err    error
+	)
+
+	resp, err = c.conn.SystemService().ClustersService().ClusterService(id).
+		AffinityGroupsService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if groups, ok = resp.Groups(); !ok {
+		return &ovirtsdk.AffinityGroupSlice{}, nil
+	}
+	return groups, nil
+}
+
+// vmsPlacement returns the host Id where each running VM Id is placed from cache
+func (c *OVirtCollector) vmsPlacement() map[string]string {
+	var (
+		ho         *ovirtsdk.Host
+		placement  = make(map[string]string)
+		vmid, hoid string
+		ok         bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if vmid, ok = vm.Id(); !ok {
+			continue
+		}
+		if ho, ok = vm.Host(); ok {
+			if hoid, ok = ho.Id(); ok {
+				placement[vmid] = hoid
+			}
+		}
+	}
+	return placement
+}
+
+// vmNameFromID returns a VM's name given its Id from cache
+func (c *OVirtCollector) vmNameFromID(id string) string {
+	var vmid, name string
+	var ok bool
+
+	for _, vm := range c.vms.Slice() {
+		if vmid, ok = vm.Id(); ok {
+			if vmid == id {
+				name, _ = vm.Name()
+				break
+			}
+		}
+	}
+	return name
+}
+
+// affinityGroupRules returns the VMs and hosts rules of an affinity group. Groups
+// without rules use the legacy positive and enforcing VM settings.
+func affinityGroupRules(ag *ovirtsdk.AffinityGroup) (affinityRule, affinityRule) {
+	var (
+		rule               *ovirtsdk.AffinityRule
+		vmsrule, hostsrule affinityRule
+		ok                 bool
+	)
+
+	if rule, ok = ag.VmsRule(); ok {
+		vmsrule.enabled, _ = rule.Enabled()
+		vmsrule.positive, _ = rule.Positive()
+		vmsrule.enforcing, _ = rule.Enforcing()
+	} else if vmsrule.positive, ok = ag.Positive(); ok {
+		vmsrule.enabled = true
+		vmsrule.enforcing, _ = ag.Enforcing()
+	}
+	if rule, ok = ag.HostsRule(); ok {
+		hostsrule.enabled, _ = rule.Enabled()
+		hostsrule.positive, _ = rule.Positive()
+		hostsrule.enforcing, _ = rule.Enforcing()
+	}
+	return vmsrule, hostsrule
+}
+
+// affinityGroupMembers returns the VM and host Ids of an affinity group
+func affinityGroupMembers(ag *ovirtsdk.AffinityGroup) ([]string, []string) {
+	var (
+		vms          *ovirtsdk.VmSlice
+		hosts        *ovirtsdk.HostSlice
+		vmids, hoids []string
+		id           string
+		ok           bool
+	)
+
+	if vms, ok = ag.Vms(); ok {
+		for _, vm := range vms.Slice() {
+			if id, ok = vm.Id(); ok {
+				vmids = append(vmids, id)
+			}
+		}
+	}
+	if hosts, ok = ag.Hosts(); ok {
+		for _, ho := range hosts.Slice() {
+			if id, ok = ho.Id(); ok {
+				hoids = append(hoids, id)
+			}
+		}
+	}
+	return vmids, hoids
+}
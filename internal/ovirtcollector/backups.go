@@ -0,0 +1,346 @@
+// This file contains ovirtcollector methods to gathers stats about image transfers,
+// VM backups and checkpoints
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectBackupsInfo gathers oVirt image transfers, VM backups and checkpoints info
+func (c *OVirtCollector) CollectBackupsInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var err error
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get backups info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	if err = c.collectImageTransfers(acc); err != nil {
+		return fmt.Errorf("could not get image transfers info: %w", err)
+	}
+	c.collectVMBackups(acc)
+
+	return nil
+}
+
+// collectImageTransfers adds image transfers info to the accumulator
+func (c *OVirtCollector) collectImageTransfers(acc *metric.Accumulator) error {
+	var (
+		phase                ovirtsdk.ImageTransferPhase
+		direction            ovirtsdk.ImageTransferDirection
+		resp                 *ovirtsdk.ImageTransfersServiceListResponse
+		transfers            *ovirtsdk.ImageTransferSlice
+		ho                   *ovirtsdk.Host
+		bk                   *ovirtsdk.Backup
+		ittags               = make(map[string]string)
+		itfields             = make(map[string]interface{})
+		id, hostname, backup string
+		diskid, diskname     string
+		t                    time.Time
+		transferred, total   int64
+		timeout              int64
+		ok                   bool
+		err                  error
+	)
+
+	resp, err = c.conn.SystemService().ImageTransfersService().List().Follow("disk").Send()
+	if err != nil {
+		return err
+	}
+	t = time.Now()
+	if transfers, ok = resp.ImageTransfer(); !ok {
+		transfers = &ovirtsdk.ImageTransferSlice{}
+	}
+
+	for _, it := range transfers.Slice() {
+		if id, ok = it.Id(); !ok {
+			acc.AddError(errors.New("found an image transfer without Id, skipping"))
+			continue
+		}
+		if phase, ok = it.Phase(); !ok {
+			phase = ovirtsdk.IMAGETRANSFERPHASE_UNKNOWN
+		}
+		c.itStates.see(acc, c.url.Host, id, id, t)
+		c.itStates.update(acc, c.url.Host, id, id, string(phase), imageTransferPhaseCode(phase), t)
+		direction, _ = it.Direction()
+		transferred, _ = it.Transferred()
+		timeout, _ = it.InactivityTimeout()
+		hostname = ""
+		if ho, ok = it.Host(); ok {
+			hostname = c.hostName(ho)
+		}
+		backup = ""
+		if bk, ok = it.Backup(); ok {
+			backup, _ = bk.Id()
+		}
+		diskid, diskname, total = imageTransferDisk(it)
+
+		ittags["backup_id"] = backup
+		ittags["disk_id"] = diskid
+		ittags["disk_name"] = diskname
+		ittags["hostname"] = hostname
+		ittags["id"] = id
+		ittags["ovirt-engine"] = c.url.Host
+
+		itfields["age_seconds"] = c.itStates.age(id, t)
+		itfields["direction"] = string(direction)
+		itfields["inactivity_timeout"] = timeout
+		itfields["phase"] = string(phase)
+		itfields["phase_code"] = imageTransferPhaseCode(phase)
+		itfields["progress_percent"] = percentage(transferred, total)
+		itfields["total_bytes"] = total
+		itfields["transferred_bytes"] = transferred
+
+		acc.AddFields("ovirtstat_image_transfer", itfields, ittags, t)
+	}
+	c.itStates.flush(acc, c.url.Host, t)
+
+	return nil
+}
+
+// collectVMBackups adds backups and checkpoints info of each VM to the accumulator
+func (c *OVirtCollector) collectVMBackups(acc *metric.Accumulator) {
+	var (
+		phase                ovirtsdk.BackupPhase
+		state                ovirtsdk.CheckpointState
+		cl                   *ovirtsdk.Cluster
+		ho                   *ovirtsdk.Host
+		backups              *ovirtsdk.BackupSlice
+		checkpoints          *ovirtsdk.CheckpointSlice
+		disks                *ovirtsdk.DiskSlice
+		bktags               = make(map[string]string)
+		bkfields             = make(map[string]interface{})
+		vmid, vmname, dcname string
+		clname, id           string
+		fromcp, tocp         string
+		t, date, last        time.Time
+		invalid, ndisks      int
+		ok                   bool
+		err                  error
+	)
+
+	t = time.Now()
+	for _, vm := range c.vms.Slice() {
+		if vmid, ok = vm.Id(); !ok {
+			acc.AddError(errors.New("found a VM without Id, skipping"))
+			continue
+		}
+		if vmname, ok = vm.Name(); !ok {
+			acc.AddError(errors.New("found a VM without Name, skipping"))
+			continue
+		}
+		if !c.filterVms.Match(vmname) {
+			continue
+		}
+		if ho, ok = vm.Host(); ok {
+			if !c.filterHosts.Match(c.hostName(ho)) {
+				continue
+			}
+		}
+		clname, dcname = "", ""
+		if cl, ok = vm.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+
+		if backups, err = c.vmBackups(vmid); err != nil {
+			acc.AddError(fmt.Errorf("could not get backups of VM %s: %w", vmname, err))
+			continue
+		}
+		for _, bk := range backups.Slice() {
+			if id, ok = bk.Id(); !ok {
+				acc.AddError(fmt.Errorf("found a backup without Id in VM %s, skipping", vmname))
+				continue
+			}
+			phase, _ = bk.Phase()
+			date, _ = bk.CreationDate()
+			fromcp, _ = bk.FromCheckpointId()
+			tocp, _ = bk.ToCheckpointId()
+			ndisks = 0
+			if disks, ok = bk.Disks(); ok {
+				ndisks = len(disks.Slice())
+			}
+
+			bktags["clustername"] = clname
+			bktags["dcname"] = dcname
+			bktags["id"] = id
+			bktags["ovirt-engine"] = c.url.Host
+			bktags["vm_id"] = vmid
+			bktags["vmname"] = vmname
+
+			bkfields["age_seconds"] = secondsSince(date, t)
+			bkfields["creation_date"] = unixTime(date)
+			bkfields["disks"] = ndisks
+			bkfields["from_checkpoint_id"] = fromcp
+			bkfields["phase"] = string(phase)
+			bkfields["phase_code"] = backupPhaseCode(phase)
+			bkfields["to_checkpoint_id"] = tocp
+
+			acc.AddFields("ovirtstat_vm_backup", bkfields, bktags, t)
+		}
+
+		if checkpoints, err = c.vmCheckpoints(vmid); err != nil {
+			acc.AddError(fmt.Errorf("could not get checkpoints of VM %s: %w", vmname, err))
+			continue
+		}
+		if len(checkpoints.Slice()) == 0 {
+			continue
+		}
+		invalid, last = 0, time.Time{}
+		for _, cp := range checkpoints.Slice() {
+			if state, ok = cp.State(); ok && state == ovirtsdk.CHECKPOINTSTATE_INVALID {
+				invalid++
+			}
+			if date, ok = cp.CreationDate(); ok && date.After(last) {
+				last = date
+			}
+		}
+
+		acc.AddFields(
+			"ovirtstat_vm_checkpoints",
+			map[string]interface{}{
+				"checkpoints":         len(checkpoints.Slice()),
+				"checkpoints_invalid": invalid,
+				"last_age_seconds":    secondsSince(last, t),
+			},
+			map[string]string{
+				"clustername":  clname,
+				"dcname":       dcname,
+				"ovirt-engine": c.url.Host,
+				"vm_id":        vmid,
+				"vmname":       vmname,
+			},
+			t,
+		)
+	}
+}
+
+// vmBackups returns the backups of a VM
+func (c *OVirtCollector) vmBackups(vmid string) (*ovirtsdk.BackupSlice, error) {
+	var (
+		resp    *ovirtsdk.VmBackupsServiceListResponse
+		backups *ovirtsdk.BackupSlice
+		ok      bool
+		err     error
+	)
+
+	resp, err = c.conn.SystemService().VmsService().VmService(vmid).
+		BackupsService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if backups, ok = resp.Backups(); !ok {
+		return &ovirtsdk.BackupSlice{}, nil
+	}
+	return backups, nil
+}
+
+// vmCheckpoints returns the checkpoints of a VM
+func (c *OVirtCollector) vmCheckpoints(vmid string) (*ovirtsdk.CheckpointSlice, error) {
+	var (
+		resp        *ovirtsdk.VmCheckpointsServiceListResponse
+		checkpoints *ovirtsdk.CheckpointSlice
+		ok          bool
+		err         error
+	)
+
+	resp, err = c.conn.SystemService().VmsService().VmService(vmid).
+		CheckpointsService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if checkpoints, ok = resp.Checkpoints(); !ok {
+		return &ovirtsdk.CheckpointSlice{}, nil
+	}
+	return checkpoints, nil
+}
+
+// imageTransferDisk returns the disk Id, alias and size in bytes of an image transfer
+func imageTransferDisk(it *ovirtsdk.ImageTransfer) (string, string, int64) {
+	var (
+		disk      *ovirtsdk.Disk
+		img       *ovirtsdk.Image
+		id, alias string
+		size      int64
+		ok        bool
+	)
+
+	if disk, ok = it.Disk(); ok {
+		id, _ = disk.Id()
+		alias, _ = disk.Alias()
+		size, _ = disk.ProvisionedSize()
+	}
+	if size == 0 {
+		if img, ok = it.Image(); ok {
+			size, _ = img.Size()
+		}
+	}
+	return id, alias, size
+}
+
+// percentage returns value as a percentage of total
+func percentage(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(total)
+}
+
+// imageTransferPhaseCode converts ImageTransferPhase to int16 for easy alerting
+func imageTransferPhaseCode(phase ovirtsdk.ImageTransferPhase) int16 {
+	switch phase {
+	case ovirtsdk.IMAGETRANSFERPHASE_INITIALIZING,
+		ovirtsdk.IMAGETRANSFERPHASE_TRANSFERRING,
+		ovirtsdk.IMAGETRANSFERPHASE_RESUMING,
+		ovirtsdk.IMAGETRANSFERPHASE_FINALIZING_SUCCESS,
+		ovirtsdk.IMAGETRANSFERPHASE_FINISHED_SUCCESS:
+		return 0
+	case ovirtsdk.IMAGETRANSFERPHASE_PAUSED_SYSTEM,
+		ovirtsdk.IMAGETRANSFERPHASE_PAUSED_USER,
+		ovirtsdk.IMAGETRANSFERPHASE_CANCELLED_USER,
+		ovirtsdk.IMAGETRANSFERPHASE_FINALIZING_CLEANUP,
+		ovirtsdk.IMAGETRANSFERPHASE_FINISHED_CLEANUP:
+		return 1
+	case ovirtsdk.IMAGETRANSFERPHASE_CANCELLED,
+		ovirtsdk.IMAGETRANSFERPHASE_CANCELLED_SYSTEM,
+		ovirtsdk.IMAGETRANSFERPHASE_FINALIZING_FAILURE,
+		ovirtsdk.IMAGETRANSFERPHASE_FINISHED_FAILURE:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// backupPhaseCode converts BackupPhase to int16 for easy alerting
+func backupPhaseCode(phase ovirtsdk.BackupPhase) int16 {
+	switch phase {
+	case ovirtsdk.BACKUPPHASE_INITIALIZING,
+		ovirtsdk.BACKUPPHASE_STARTING,
+		ovirtsdk.BACKUPPHASE_READY,
+		ovirtsdk.BACKUPPHASE_FINALIZING,
+		ovirtsdk.BACKUPPHASE_SUCCEEDED:
+		return 0
+	case ovirtsdk.BACKUPPHASE_FAILED:
+		return 2
+	default:
+		return 1
+	}
+}
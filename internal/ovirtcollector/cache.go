@@ -8,6 +8,7 @@ package ovirtcollector
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	ovirtsdk "github.com/ovirt/go-ovirt"
@@ -19,14 +20,30 @@ type VcCache struct {
 	sds          *ovirtsdk.StorageDomainSlice
 	hosts        *ovirtsdk.HostSlice
 	vms          *ovirtsdk.VmSlice
+	tps          *ovirtsdk.TemplateSlice
+	its          *ovirtsdk.InstanceTypeSlice
+	pools        *ovirtsdk.VmPoolSlice
 	lastDCUpdate time.Time
 	lastHoUpdate time.Time
 	lastSdUpdate time.Time
 	lastVMUpdate time.Time
+	lastTpUpdate time.Time
+	lastItUpdate time.Time
+	lastPoUpdate time.Time
+	dcDuration   time.Duration
+	hoDuration   time.Duration
+	sdDuration   time.Duration
+	vmDuration   time.Duration
+	tpDuration   time.Duration
+	poDuration   time.Duration
+	hoAllContent bool
+	hoFollows    []string
+	tpFollows    []string
+	vmFollows    []string
 }
 
 func (c *OVirtCollector) getDatacentersAndClusters(_ context.Context) error {
-	if time.Since(c.lastDCUpdate) < c.dataDuration {
+	if time.Since(c.lastDCUpdate) < c.dcDuration {
 		return nil
 	}
 
@@ -62,7 +79,7 @@ func (c *OVirtCollector) getDatacentersAndClusters(_ context.Context) error {
 func (c *OVirtCollector) getAllDatacentersHosts(ctx context.Context) error {
 	var err error
 
-	if time.Since(c.lastHoUpdate) < c.dataDuration {
+	if time.Since(c.lastHoUpdate) < c.hoDuration {
 		return nil
 	}
 	if err = c.getDatacentersAndClusters(ctx); err != nil {
@@ -71,7 +88,11 @@ func (c *OVirtCollector) getAllDatacentersHosts(ctx context.Context) error {
 
 	// Get hosts
 	hostsService := c.conn.SystemService().HostsService()
-	hostsResponse, err := hostsService.List().Send()
+	hostsRequest := hostsService.List().AllContent(c.hoAllContent)
+	if len(c.hoFollows) > 0 {
+		hostsRequest.Follow(strings.Join(c.hoFollows, ","))
+	}
+	hostsResponse, err := hostsRequest.Send()
 	if err != nil {
 		return err
 	}
@@ -88,7 +109,7 @@ func (c *OVirtCollector) getAllDatacentersHosts(ctx context.Context) error {
 func (c *OVirtCollector) getAllDatacentersStorageDomains(ctx context.Context) error {
 	var err error
 
-	if time.Since(c.lastSdUpdate) < c.dataDuration {
+	if time.Since(c.lastSdUpdate) < c.sdDuration {
 		return nil
 	}
 	if err = c.getDatacentersAndClusters(ctx); err != nil {
@@ -114,7 +135,7 @@ func (c *OVirtCollector) getAllDatacentersStorageDomains(ctx context.Context) er
 func (c *OVirtCollector) getAllDatacentersVMs(ctx context.Context) error {
 	var err error
 
-	if time.Since(c.lastVMUpdate) < c.dataDuration {
+	if time.Since(c.lastVMUpdate) < c.vmDuration {
 		return nil
 	}
 	if err = c.getAllDatacentersHosts(ctx); err != nil {
@@ -123,7 +144,11 @@ func (c *OVirtCollector) getAllDatacentersVMs(ctx context.Context) error {
 
 	// Get all	 VMs
 	vmsService := c.conn.SystemService().VmsService()
-	vmsResponse, err := vmsService.List().Send()
+	vmsRequest := vmsService.List()
+	if len(c.vmFollows) > 0 {
+		vmsRequest.Follow(strings.Join(c.vmFollows, ","))
+	}
+	vmsResponse, err := vmsRequest.Send()
 	if err != nil {
 		return err
 	}
@@ -137,6 +162,84 @@ func (c *OVirtCollector) getAllDatacentersVMs(ctx context.Context) error {
 	return nil
 }
 
+func (c *OVirtCollector) getAllTemplates(ctx context.Context) error {
+	var err error
+
+	if time.Since(c.lastTpUpdate) < c.tpDuration {
+		return nil
+	}
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return err
+	}
+
+	// Get all templates
+	tpsService := c.conn.SystemService().TemplatesService()
+	tpsRequest := tpsService.List()
+	if len(c.tpFollows) > 0 {
+		tpsRequest.Follow(strings.Join(c.tpFollows, ","))
+	}
+	tpsResponse, err := tpsRequest.Send()
+	if err != nil {
+		return err
+	}
+	tps, ok := tpsResponse.Templates()
+	if !ok {
+		return errors.New("could not get template list or it is empty")
+	}
+	c.tps = tps
+	c.lastTpUpdate = time.Now()
+
+	return nil
+}
+
+// getAllInstanceTypes gets instance types, which are kept as long as templates
+func (c *OVirtCollector) getAllInstanceTypes(_ context.Context) error {
+	if time.Since(c.lastItUpdate) < c.tpDuration {
+		return nil
+	}
+
+	// Get all instance types
+	itsService := c.conn.SystemService().InstanceTypesService()
+	itsResponse, err := itsService.List().Send()
+	if err != nil {
+		return err
+	}
+	its, ok := itsResponse.InstanceType()
+	if !ok {
+		its = &ovirtsdk.InstanceTypeSlice{}
+	}
+	c.its = its
+	c.lastItUpdate = time.Now()
+
+	return nil
+}
+
+func (c *OVirtCollector) getAllVMPools(ctx context.Context) error {
+	var err error
+
+	if time.Since(c.lastPoUpdate) < c.poDuration {
+		return nil
+	}
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return err
+	}
+
+	// Get all VM pools
+	poolsService := c.conn.SystemService().VmPoolsService()
+	poolsResponse, err := poolsService.List().Send()
+	if err != nil {
+		return err
+	}
+	pools, ok := poolsResponse.Pools()
+	if !ok {
+		return errors.New("could not get VM pool list or it is empty")
+	}
+	c.pools = pools
+	c.lastPoUpdate = time.Now()
+
+	return nil
+}
+
 // datacenterNameFromID returns the datacenter name given its Id
 func (c *OVirtCollector) datacenterNameFromID(id string) string {
 	var clid, name string
@@ -217,6 +320,44 @@ func (c *OVirtCollector) clusterDatacenterName(cl *ovirtsdk.Cluster) string {
 	return name
 }
 
+// clusterDatacenterID returns a cluster's datacenter Id from cache
+func (c *OVirtCollector) clusterDatacenterID(cl *ovirtsdk.Cluster) string {
+	var dc *ovirtsdk.DataCenter
+	var clid, id, dcid string
+	var ok bool
+
+	if id, ok = cl.Id(); !ok {
+		return dcid
+	}
+	for _, cl := range c.clusters.Slice() {
+		if clid, ok = cl.Id(); ok {
+			if clid == id {
+				if dc, ok = cl.DataCenter(); ok {
+					dcid, _ = dc.Id()
+				}
+				break
+			}
+		}
+	}
+	return dcid
+}
+
+// hostNameFromID returns a host's name given its Id from cache
+func (c *OVirtCollector) hostNameFromID(id string) string {
+	var hoid, name string
+	var ok bool
+
+	for _, h := range c.hosts.Slice() {
+		if hoid, ok = h.Id(); ok {
+			if hoid == id {
+				name, _ = h.Name()
+				break
+			}
+		}
+	}
+	return name
+}
+
 // hostName returns a host's name from cache
 func (c *OVirtCollector) hostName(ho *ovirtsdk.Host) string {
 	var hoid, id, name string
@@ -235,3 +376,57 @@ func (c *OVirtCollector) hostName(ho *ovirtsdk.Host) string {
 	}
 	return name
 }
+
+// storageDomainNameFromID returns a storagedomain's name given its Id from cache
+func (c *OVirtCollector) storageDomainNameFromID(id string) string {
+	var sdid, name string
+	var ok bool
+
+	for _, s := range c.sds.Slice() {
+		if sdid, ok = s.Id(); ok {
+			if sdid == id {
+				name, _ = s.Name()
+				break
+			}
+		}
+	}
+	return name
+}
+
+// storageDomainName returns a storagedomain's name from cache
+func (c *OVirtCollector) storageDomainName(sd *ovirtsdk.StorageDomain) string {
+	id, _ := sd.Id()
+	return c.storageDomainNameFromID(id)
+}
+
+// templateNameFromID returns a template's name given its Id from cache
+func (c *OVirtCollector) templateNameFromID(id string) string {
+	var tpid, name string
+	var ok bool
+
+	for _, tp := range c.tps.Slice() {
+		if tpid, ok = tp.Id(); ok {
+			if tpid == id {
+				name, _ = tp.Name()
+				break
+			}
+		}
+	}
+	return name
+}
+
+// vmPoolNameFromID returns a VM pool's name given its Id from cache
+func (c *OVirtCollector) vmPoolNameFromID(id string) string {
+	var poid, name string
+	var ok bool
+
+	for _, pool := range c.pools.Slice() {
+		if poid, ok = pool.Id(); ok {
+			if poid == id {
+				name, _ = pool.Name()
+				break
+			}
+		}
+	}
+	return name
+}
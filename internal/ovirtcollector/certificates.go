@@ -0,0 +1,172 @@
+// This file contains ovirtcollector methods to gathers stats about engine certificates
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	ctls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tesibelda/lightmetric/metric"
+
+	"github.com/tesibelda/ovirtstat/internal/netplus"
+)
+
+const (
+	// pkiCAPath is the engine's PKI resource path to download its CA certificate
+	pkiCAPath = "/ovirt-engine/services/pki-resource?resource=ca-certificate&format=X509-PEM-CA"
+	// certificatesRefresh is the interval to capture again the engine certificate chain
+	certificatesRefresh = time.Hour
+)
+
+// CollectCertificatesInfo gathers oVirt engine certificate chain captured when the
+// session was opened, or again every certificatesRefresh, and optionally the engine's
+// PKI CA certificate
+func (c *OVirtCollector) CollectCertificatesInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		ca   *x509.Certificate
+		role string
+		t    time.Time
+		err  error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get certificates info: %w", ErrorNoClient)
+	}
+	if time.Since(c.certsTime) >= certificatesRefresh {
+		c.captureCertificates(ctx)
+	}
+	if c.certsErr != nil {
+		return fmt.Errorf("could not get engine certificate chain: %w", c.certsErr)
+	}
+	t = time.Now()
+
+	for i, cert := range c.certs {
+		role = "ca"
+		if i == 0 {
+			role = "leaf"
+		} else if !cert.IsCA || cert.Subject.String() != cert.Issuer.String() {
+			role = "intermediate"
+		}
+		c.addCertificate(acc, cert, role, "tls", t)
+	}
+
+	if c.pkiCA {
+		if ca, err = c.downloadPKICA(ctx); err != nil {
+			return fmt.Errorf("could not get engine PKI CA certificate: %w", err)
+		}
+		c.addCertificate(acc, ca, "ca", "pki", t)
+	}
+
+	return nil
+}
+
+// addCertificate adds an ovirtstat_certificate metric to the accumulator
+func (c *OVirtCollector) addCertificate(
+	acc *metric.Accumulator,
+	cert *x509.Certificate,
+	role, source string,
+	t time.Time,
+) {
+	acc.AddFields(
+		"ovirtstat_certificate",
+		map[string]interface{}{
+			"days_remaining": netplus.DaysUntil(cert.NotAfter, t),
+			"not_after":      cert.NotAfter.Unix(),
+			"not_before":     cert.NotBefore.Unix(),
+		},
+		map[string]string{
+			"issuer":       cert.Issuer.String(),
+			"ovirt-engine": c.url.Host,
+			"role":         role,
+			"serial":       cert.SerialNumber.String(),
+			"source":       source,
+			"subject":      cert.Subject.String(),
+		},
+		t,
+	)
+}
+
+// captureCertificates keeps the certificate chain presented by the engine
+func (c *OVirtCollector) captureCertificates(ctx context.Context) {
+	c.certs, c.certsErr = netplus.PeerCertificates(
+		ctx,
+		c.engineAddress(),
+		c.timeout,
+		&ctls.Config{
+			InsecureSkipVerify: true, //nolint:gosec // only the chain is read
+			ServerName:         c.url.Hostname(),
+		},
+	)
+	c.certsTime = time.Now()
+}
+
+// downloadPKICA returns the engine's CA certificate downloaded from its PKI resource
+func (c *OVirtCollector) downloadPKICA(ctx context.Context) (*x509.Certificate, error) {
+	var (
+		tlscfg *ctls.Config
+		req    *http.Request
+		resp   *http.Response
+		block  *pem.Block
+		body   []byte
+		err    error
+	)
+
+	if c.pkiClient == nil {
+		if tlscfg, err = c.TLSConfig(); err != nil {
+			return nil, err
+		}
+		c.pkiClient = &http.Client{
+			Timeout:   c.timeout,
+			Transport: &http.Transport{TLSClientConfig: tlscfg},
+		}
+	}
+	req, err = http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		c.url.Scheme+"://"+c.url.Host+pkiCAPath,
+		http.NoBody,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resp, err = c.pkiClient.Do(req); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return nil, err
+	}
+	if block, _ = pem.Decode(body); block == nil {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// engineAddress returns the engine's host and port to connect to
+func (c *OVirtCollector) engineAddress() string {
+	port := c.url.Port()
+	if port == "" {
+		port = "443"
+		if c.url.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(c.url.Hostname(), port)
+}
@@ -0,0 +1,481 @@
+// This file contains ovirtcollector methods to gathers stats about clusters and their
+// scheduling policies
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// hostLoadStatistic is the host statistic used as host load in cluster balance indicators
+const hostLoadStatistic = "cpu.load.avg.5m"
+
+// clusterHosts contains the per host data of a cluster used for balance indicators
+type clusterHosts struct {
+	total, up int
+	memory    int64
+	memusage  []float64
+	vms       []float64
+	loads     []float64
+}
+
+// CollectClustersInfo gathers oVirt cluster's scheduling policy and balance indicators
+// computed from the cluster hosts
+func (c *OVirtCollector) CollectClustersInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		sp             *ovirtsdk.SchedulingPolicy
+		policies       map[string]*ovirtsdk.SchedulingPolicy
+		units          map[string]string
+		props          *ovirtsdk.PropertySlice
+		perhost        map[string]*clusterHosts
+		ch             *clusterHosts
+		committed      map[string]int64
+		cltags         = make(map[string]string)
+		clkeys         = make(map[string]bool)
+		clfields       map[string]interface{}
+		id, name, spid string
+		avg, stddev    float64
+		lmax, lmin     float64
+		capacity       int64
+		t              time.Time
+		ok             bool
+		err            error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get clusters info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all hosts entity lists: %w", err)
+	}
+	// scheduling policies are kept as long as clusters cache, as they rarely change
+	if c.spPolicies == nil || !c.spUpdate.Equal(c.lastDCUpdate) {
+		if units, err = c.schedulingPolicyUnits(); err != nil {
+			return fmt.Errorf("could not get scheduling policy unit list: %w", err)
+		}
+		if policies, err = c.schedulingPolicies(); err != nil {
+			return fmt.Errorf("could not get scheduling policy list: %w", err)
+		}
+		c.spUnits, c.spPolicies = units, policies
+		c.spUpdate = c.lastDCUpdate
+	}
+	units, policies = c.spUnits, c.spPolicies
+	perhost = c.clustersHosts()
+	if c.forecast != nil {
+		if err = c.getAllDatacentersVMs(ctx); err != nil {
+			return fmt.Errorf("could not get all VM entity lists: %w", err)
+		}
+		committed = c.clustersCommittedMemory()
+	}
+	t = time.Now()
+
+	for _, cl := range c.clusters.Slice() {
+		if id, ok = cl.Id(); !ok {
+			acc.AddError(errors.New("found a cluster without Id, skipping"))
+			continue
+		}
+		if name, ok = cl.Name(); !ok {
+			acc.AddError(errors.New("found a cluster without Name, skipping"))
+			continue
+		}
+		if !c.filterClusters.Match(name) {
+			continue
+		}
+
+		cltags["dcname"] = c.clusterDatacenterName(cl)
+		cltags["id"] = id
+		cltags["name"] = name
+		cltags["ovirt-engine"] = c.url.Host
+
+		clfields = make(map[string]interface{})
+		if sp, ok = cl.SchedulingPolicy(); ok {
+			spid, _ = sp.Id()
+			if sp, ok = policies[spid]; ok {
+				addSchedulingPolicyFields(clfields, sp, units)
+			}
+		}
+		if props, ok = cl.CustomSchedulingPolicyProperties(); ok {
+			addSchedulingPropertiesFields(clfields, props)
+		}
+
+		if ch, ok = perhost[id]; !ok {
+			ch = &clusterHosts{}
+		}
+		clfields["hosts"] = ch.total
+		clfields["hosts_up"] = ch.up
+		if len(ch.memusage) > 0 {
+			avg, stddev = meanStddev(ch.memusage)
+			clfields["memory_usage_avg"] = avg
+			clfields["memory_usage_stddev"] = stddev
+		}
+		if len(ch.vms) > 0 {
+			avg, stddev = meanStddev(ch.vms)
+			clfields["vms_per_host_avg"] = avg
+			clfields["vms_per_host_stddev"] = stddev
+		}
+		if len(ch.loads) > 0 {
+			lmax, lmin = ch.loads[0], ch.loads[0]
+			for _, l := range ch.loads {
+				lmax = max(lmax, l)
+				lmin = min(lmin, l)
+			}
+			clfields["host_load_max"] = lmax
+			clfields["host_load_min"] = lmin
+			if lmin > 0 {
+				clfields["host_load_ratio"] = lmax / lmin
+			}
+		}
+
+		if c.forecast != nil {
+			capacity = ch.memory * clusterOverCommitPercent(cl) / 100
+			clfields["memory_capacity"] = capacity
+			clfields["memory_committed"] = committed[id]
+			clkeys["cluster/"+id] = true
+			c.forecast.addFields(
+				clfields, "cluster/"+id, t, float64(committed[id]), float64(capacity),
+			)
+		}
+
+		acc.AddFields("ovirtstat_cluster", clfields, cltags, t)
+	}
+	if c.forecast != nil {
+		c.forecast.forget("cluster/", clkeys)
+		if err = c.forecast.save(); err != nil {
+			acc.AddError(fmt.Errorf("could not save forecast state: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// clustersCommittedMemory returns the memory of VMs not down per cluster Id from cache
+func (c *OVirtCollector) clustersCommittedMemory() map[string]int64 {
+	var (
+		status    ovirtsdk.VmStatus
+		cl        *ovirtsdk.Cluster
+		committed = make(map[string]int64)
+		clid      string
+		mem       int64
+		ok        bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if status, ok = vm.Status(); !ok || status == ovirtsdk.VMSTATUS_DOWN {
+			continue
+		}
+		if cl, ok = vm.Cluster(); !ok {
+			continue
+		}
+		if clid, ok = cl.Id(); !ok {
+			continue
+		}
+		mem, _ = vm.Memory()
+		committed[clid] += mem
+	}
+	return committed
+}
+
+// clusterOverCommitPercent returns the memory over commit percentage of a cluster,
+// which is 100 if unknown
+func clusterOverCommitPercent(cl *ovirtsdk.Cluster) int64 {
+	if mp, ok := cl.MemoryPolicy(); ok {
+		if oc, ok := mp.OverCommit(); ok {
+			if percent, ok := oc.Percent(); ok && percent > 0 {
+				return percent
+			}
+		}
+	}
+	return 100
+}
+
+// clustersHosts returns the balance indicators data of up hosts per cluster Id from
+// cache. Memory usage and load are only available when host statistics are followed.
+func (c *OVirtCollector) clustersHosts() map[string]*clusterHosts {
+	var (
+		status       ovirtsdk.HostStatus
+		cl           *ovirtsdk.Cluster
+		stats        *ovirtsdk.StatisticSlice
+		vmsumm       *ovirtsdk.VmSummary
+		values       map[string]float64
+		perhost      = make(map[string]*clusterHosts)
+		clid         string
+		vmact, mem   int64
+		used, total  float64
+		load         float64
+		ok, uok, tok bool
+	)
+
+	for _, host := range c.hosts.Slice() {
+		if cl, ok = host.Cluster(); !ok {
+			continue
+		}
+		if clid, ok = cl.Id(); !ok {
+			continue
+		}
+		if _, ok = perhost[clid]; !ok {
+			perhost[clid] = &clusterHosts{}
+		}
+		ch := perhost[clid]
+		ch.total++
+		if status, ok = host.Status(); !ok || status != ovirtsdk.HOSTSTATUS_UP {
+			continue
+		}
+		ch.up++
+		if mem, ok = host.Memory(); ok {
+			ch.memory += mem
+		}
+		vmact = 0
+		if vmsumm, ok = host.Summary(); ok {
+			vmact, _ = vmsumm.Active()
+		}
+		ch.vms = append(ch.vms, float64(vmact))
+		if stats, ok = host.Statistics(); !ok {
+			continue
+		}
+		values = statisticsFloats(stats)
+		used, uok = values["memory.used"]
+		total, tok = values["memory.total"]
+		if uok && tok && total > 0 {
+			ch.memusage = append(ch.memusage, used*100/total)
+		}
+		if load, ok = values[hostLoadStatistic]; ok {
+			ch.loads = append(ch.loads, load)
+		}
+	}
+	return perhost
+}
+
+// addSchedulingPolicyFields adds a scheduling policy name, its filter, weight and
+// balance modules and its properties to the given fields
+func addSchedulingPolicyFields(
+	fields map[string]interface{},
+	sp *ovirtsdk.SchedulingPolicy,
+	units map[string]string,
+) {
+	var (
+		filters  *ovirtsdk.FilterSlice
+		weights  *ovirtsdk.WeightSlice
+		balances *ovirtsdk.BalanceSlice
+		props    *ovirtsdk.PropertySlice
+		modules  []string
+		name     string
+		factor   int64
+		ok       bool
+	)
+
+	name, _ = sp.Name()
+	fields["scheduling_policy"] = name
+
+	if filters, ok = sp.Filters(); ok {
+		sorted := filters.Slice()
+		sort.SliceStable(sorted, func(i, j int) bool {
+			pi, _ := sorted[i].Position()
+			pj, _ := sorted[j].Position()
+			return pi < pj
+		})
+		for _, f := range sorted {
+			modules = append(modules, policyUnitName(f.SchedulingPolicyUnit, f.Name, units))
+		}
+	}
+	fields["scheduling_filters"] = strings.Join(modules, ",")
+
+	modules = nil
+	if weights, ok = sp.Weight(); ok {
+		for _, w := range weights.Slice() {
+			factor, _ = w.Factor()
+			name = policyUnitName(w.SchedulingPolicyUnit, w.Name, units)
+			modules = append(modules, name+":"+strconv.FormatInt(factor, 10))
+		}
+	}
+	fields["scheduling_weights"] = strings.Join(modules, ",")
+
+	modules = nil
+	if balances, ok = sp.Balances(); ok {
+		for _, b := range balances.Slice() {
+			modules = append(modules, policyUnitName(b.SchedulingPolicyUnit, b.Name, units))
+		}
+	}
+	fields["scheduling_balances"] = strings.Join(modules, ",")
+
+	if props, ok = sp.Properties(); ok {
+		addSchedulingPropertiesFields(fields, props)
+	}
+}
+
+// addSchedulingPropertiesFields adds scheduling policy properties to the given fields
+// using their name in snake case with policy_ prefix, e.g. HighUtilization becomes
+// policy_high_utilization
+func addSchedulingPropertiesFields(
+	fields map[string]interface{},
+	props *ovirtsdk.PropertySlice,
+) {
+	var (
+		name, value string
+		ok          bool
+	)
+
+	for _, p := range props.Slice() {
+		if name, ok = p.Name(); !ok || name == "" {
+			continue
+		}
+		value, _ = p.Value()
+		name = "policy_" + snakeCase(name)
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[name] = n
+			continue
+		}
+		fields[name] = value
+	}
+}
+
+// schedulingPolicies returns each scheduling policy by its Id including its filter,
+// weight and balance modules
+func (c *OVirtCollector) schedulingPolicies() (map[string]*ovirtsdk.SchedulingPolicy, error) {
+	var (
+		resp     *ovirtsdk.SchedulingPoliciesServiceListResponse
+		slice    *ovirtsdk.SchedulingPolicySlice
+		policies = make(map[string]*ovirtsdk.SchedulingPolicy)
+		id       string
+		ok       bool
+		err      error
+	)
+
+	resp, err = c.conn.SystemService().SchedulingPoliciesService().List().
+		Follow("filters,weights,balances").Send()
+	if err != nil {
+		return nil, err
+	}
+	if slice, ok = resp.Policies(); !ok {
+		return policies, nil
+	}
+	for _, sp := range slice.Slice() {
+		if id, ok = sp.Id(); ok {
+			policies[id] = sp
+		}
+	}
+	return policies, nil
+}
+
+// schedulingPolicyUnits returns the name of each scheduling policy unit Id
+func (c *OVirtCollector) schedulingPolicyUnits() (map[string]string, error) {
+	var (
+		resp     *ovirtsdk.SchedulingPolicyUnitsServiceListResponse
+		slice    *ovirtsdk.SchedulingPolicyUnitSlice
+		units    = make(map[string]string)
+		id, name string
+		ok       bool
+		err      error
+	)
+
+	if resp, err = c.conn.SystemService().SchedulingPolicyUnitsService().List().Send(); err != nil {
+		return nil, err
+	}
+	if slice, ok = resp.Units(); !ok {
+		return units, nil
+	}
+	for _, u := range slice.Slice() {
+		if id, ok = u.Id(); !ok {
+			continue
+		}
+		name, _ = u.Name()
+		units[id] = name
+	}
+	return units, nil
+}
+
+// policyUnitName returns the name of a scheduling policy module from its policy unit,
+// or the module's own name if the unit is unknown
+func policyUnitName(
+	unit func() (*ovirtsdk.SchedulingPolicyUnit, bool),
+	name func() (string, bool),
+	units map[string]string,
+) string {
+	if u, ok := unit(); ok {
+		if id, ok := u.Id(); ok && units[id] != "" {
+			return units[id]
+		}
+		if n, ok := u.Name(); ok {
+			return n
+		}
+	}
+	n, _ := name()
+	return n
+}
+
+// statisticsFloats returns the numeric statistics by name
+func statisticsFloats(stats *ovirtsdk.StatisticSlice) map[string]float64 {
+	var (
+		values = make(map[string]float64)
+		name   string
+		value  interface{}
+		ok     bool
+	)
+
+	for _, st := range stats.Slice() {
+		if name, ok = st.Name(); !ok {
+			continue
+		}
+		if value, ok = statisticValue(st); !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case float64:
+			values[name] = v
+		case int64:
+			values[name] = float64(v)
+		}
+	}
+	return values
+}
+
+// meanStddev returns the mean and population standard deviation of the given values
+func meanStddev(values []float64) (float64, float64) {
+	var sum, sq float64
+
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// snakeCase converts a CamelCase name to snake_case
+func snakeCase(name string) string {
+	var (
+		b     strings.Builder
+		runes = []rune(name)
+	)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -21,13 +21,21 @@ func (c *OVirtCollector) CollectDatacenterInfo(
 	acc *metric.Accumulator,
 ) error {
 	var (
-		status    ovirtsdk.DataCenterStatus
-		dctags    = make(map[string]string)
-		dcfields  = make(map[string]interface{})
-		id, name  string
-		t         time.Time
-		ok, local bool
-		err       error
+		status             ovirtsdk.DataCenterStatus
+		sformat            ovirtsdk.StorageFormat
+		quota              ovirtsdk.QuotaModeType
+		ver                *ovirtsdk.Version
+		sdcount            map[ovirtsdk.StorageDomainStatus]int
+		dctags             = make(map[string]string)
+		dcfields           = make(map[string]interface{})
+		id, name, version  string
+		spmhost, spmstatus string
+		mastersd           string
+		t                  time.Time
+		major, minor       int64
+		sdtotal, hosts     int
+		ok, local          bool
+		err                error
 	)
 
 	if c.conn == nil {
@@ -37,6 +45,15 @@ func (c *OVirtCollector) CollectDatacenterInfo(
 	if err = c.getDatacentersAndClusters(ctx); err != nil {
 		return fmt.Errorf("could not get all datacenter entity lists: %w", err)
 	}
+	// hosts and storagedomains are only used for SPM and storagedomain fields, so
+	// failing to get them must not stop datacenters from being reported
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		acc.AddError(fmt.Errorf("could not get all hosts entity lists: %w", err))
+	}
+	if err = c.getAllDatacentersStorageDomains(ctx); err != nil {
+		acc.AddError(fmt.Errorf("could not get all storagedomain entity lists: %w", err))
+	}
+	err = nil
 	t = time.Now()
 
 	for _, dc := range c.dcs.Slice() {
@@ -48,28 +65,150 @@ func (c *OVirtCollector) CollectDatacenterInfo(
 			acc.AddError(errors.New("found a datacenter without Name, skipping"))
 			continue
 		}
+		if !c.filterDatacenters.Match(name) {
+			continue
+		}
+		c.dcStates.see(acc, c.url.Host, id, name, t)
 		if status, ok = dc.Status(); !ok {
 			acc.AddError(fmt.Errorf("could not get status for datacenter %s", name))
 			continue
 		}
 		local, _ = dc.Local()
+		sformat, _ = dc.StorageFormat()
+		quota, _ = dc.QuotaMode()
+		version = ""
+		if ver, ok = dc.Version(); ok {
+			major, _ = ver.Major()
+			minor, _ = ver.Minor()
+			version = fmt.Sprintf("%d.%d", major, minor)
+		}
 		// here dc.Networks() and dc.StorageDomains() may return empty slices so not using them
+		spmhost, spmstatus, hosts = c.datacenterSpm(id)
+		mastersd, sdcount = c.datacenterStorageDomains(id)
 
 		dctags["name"] = name
 		dctags["id"] = id
 		dctags["ovirt-engine"] = c.url.Host
 
 		dcfields["clusters"] = c.countClustersInDc(id)
+		dcfields["hosts"] = hosts
 		dcfields["local"] = local
+		dcfields["master_storagedomain"] = mastersd
+		dcfields["quota_mode"] = string(quota)
+		dcfields["spm_host"] = spmhost
+		dcfields["spm_status"] = spmstatus
 		dcfields["status"] = string(status)
 		dcfields["status_code"] = datacenterStatusCode(status)
+		dcfields["storage_format"] = string(sformat)
+		sdtotal = 0
+		for _, sdstatus := range dcStorageDomainStatuses {
+			dcfields["storagedomains_"+string(sdstatus)] = sdcount[sdstatus]
+			sdtotal += sdcount[sdstatus]
+		}
+		dcfields["storagedomains"] = sdtotal
+		dcfields["version"] = version
 
 		acc.AddFields("ovirtstat_datacenter", dcfields, dctags, t)
+		c.dcStates.update(
+			acc, c.url.Host, id, name, string(status), datacenterStatusCode(status), t,
+		)
 	}
+	c.dcStates.flush(acc, c.url.Host, t)
 
 	return err
 }
 
+// dcStorageDomainStatuses are the storagedomain statuses counted per datacenter
+var dcStorageDomainStatuses = []ovirtsdk.StorageDomainStatus{
+	ovirtsdk.STORAGEDOMAINSTATUS_ACTIVATING,
+	ovirtsdk.STORAGEDOMAINSTATUS_ACTIVE,
+	ovirtsdk.STORAGEDOMAINSTATUS_DETACHING,
+	ovirtsdk.STORAGEDOMAINSTATUS_INACTIVE,
+	ovirtsdk.STORAGEDOMAINSTATUS_LOCKED,
+	ovirtsdk.STORAGEDOMAINSTATUS_MAINTENANCE,
+	ovirtsdk.STORAGEDOMAINSTATUS_MIXED,
+	ovirtsdk.STORAGEDOMAINSTATUS_PREPARING_FOR_MAINTENANCE,
+	ovirtsdk.STORAGEDOMAINSTATUS_UNKNOWN,
+}
+
+// datacenterSpm returns the SPM host name, its SPM status and the number of hosts for
+// the given datacenter Id from cache. A contending host is returned if there is no SPM
+// host yet.
+func (c *OVirtCollector) datacenterSpm(id string) (string, string, int) {
+	var (
+		spmstatus         ovirtsdk.SpmStatus
+		cl                *ovirtsdk.Cluster
+		spm               *ovirtsdk.Spm
+		honame            string
+		spmhost, spmstate string
+		contending        string
+		hosts             int
+		ok                bool
+	)
+
+	for _, host := range c.hosts.Slice() {
+		if cl, ok = host.Cluster(); !ok || c.clusterDatacenterID(cl) != id {
+			continue
+		}
+		hosts++
+		if spm, ok = host.Spm(); !ok {
+			continue
+		}
+		if spmstatus, ok = spm.Status(); !ok {
+			continue
+		}
+		honame, _ = host.Name()
+		switch spmstatus {
+		case ovirtsdk.SPMSTATUS_SPM:
+			spmhost, spmstate = honame, string(spmstatus)
+		case ovirtsdk.SPMSTATUS_CONTENDING:
+			contending = honame
+		}
+	}
+	if spmhost != "" {
+		return spmhost, spmstate, hosts
+	}
+	if contending != "" {
+		return contending, string(ovirtsdk.SPMSTATUS_CONTENDING), hosts
+	}
+	return "", string(ovirtsdk.SPMSTATUS_NONE), hosts
+}
+
+// datacenterStorageDomains returns the master storagedomain name and the number of
+// storagedomains per status attached to the given datacenter Id from cache
+func (c *OVirtCollector) datacenterStorageDomains(
+	id string,
+) (string, map[ovirtsdk.StorageDomainStatus]int) {
+	var (
+		status  ovirtsdk.StorageDomainStatus
+		dcs     *ovirtsdk.DataCenterSlice
+		dcid    string
+		master  string
+		counts  = make(map[ovirtsdk.StorageDomainStatus]int)
+		ok, ism bool
+	)
+
+	for _, sd := range c.sds.Slice() {
+		if dcs, ok = sd.DataCenters(); !ok {
+			continue
+		}
+		for _, dc := range dcs.Slice() {
+			if dcid, ok = dc.Id(); !ok || dcid != id {
+				continue
+			}
+			if status, ok = sd.Status(); !ok || status == "" {
+				status = ovirtsdk.STORAGEDOMAINSTATUS_UNKNOWN
+			}
+			counts[status]++
+			if ism, ok = sd.Master(); ok && ism {
+				master, _ = sd.Name()
+			}
+			break
+		}
+	}
+	return master, counts
+}
+
 // datacenterStatusCode converts DataCenterStatus to int16 for easy alerting
 func datacenterStatusCode(status ovirtsdk.DataCenterStatus) int16 {
 	switch status {
@@ -0,0 +1,172 @@
+// This file contains ovirtcollector methods to forecast when clusters and storagedomains
+// will run out of capacity from a rolling window of samples kept in a local state file
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultForecastWindow is the rolling window used if none is given
+	defaultForecastWindow = 7 * 24 * time.Hour
+	// forecastSamples is the max number of samples kept per entity in the window
+	forecastSamples = 288
+	// forecastMinSamples is the min number of samples needed to fit a trend
+	forecastMinSamples = 3
+)
+
+// forecastSample is a value of an entity at a unix time
+type forecastSample struct {
+	T int64   `json:"t"`
+	V float64 `json:"v"`
+}
+
+// forecaster keeps a rolling window of samples per entity persisted to a state file
+type forecaster struct {
+	path   string
+	window time.Duration
+	series map[string][]forecastSample
+}
+
+// newForecaster returns a forecaster with the samples found in the given state file
+func newForecaster(path string, window time.Duration) (*forecaster, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if window <= 0 {
+		window = defaultForecastWindow
+	}
+	f := &forecaster{
+		path:   path,
+		window: window,
+		series: make(map[string][]forecastSample),
+	}
+	if data, err = os.ReadFile(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return f, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &f.series); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// add records a sample of an entity dropping those out of the window. Samples closer
+// than window/forecastSamples to the previous one are ignored to bound the state size.
+func (f *forecaster) add(key string, t time.Time, value float64) {
+	var (
+		samples = f.series[key]
+		oldest  = t.Add(-f.window).Unix()
+		i       int
+	)
+
+	if n := len(samples); n > 0 &&
+		t.Unix()-samples[n-1].T < int64((f.window/forecastSamples).Seconds()) {
+		return
+	}
+	for i < len(samples) && samples[i].T < oldest {
+		i++
+	}
+	f.series[key] = append(samples[i:], forecastSample{T: t.Unix(), V: value})
+}
+
+// growthPerDay returns the slope per day of the least squares line fitted to the
+// samples of an entity
+func (f *forecaster) growthPerDay(key string) (float64, bool) {
+	var (
+		samples        = f.series[key]
+		mx, my         float64
+		sxy, sxx, x, n float64
+	)
+
+	if len(samples) < forecastMinSamples {
+		return 0, false
+	}
+	n = float64(len(samples))
+	for _, s := range samples {
+		mx += float64(s.T-samples[0].T) / 86400
+		my += s.V
+	}
+	mx /= n
+	my /= n
+	for _, s := range samples {
+		x = float64(s.T-samples[0].T)/86400 - mx
+		sxy += x * (s.V - my)
+		sxx += x * x
+	}
+	if sxx == 0 {
+		return 0, false
+	}
+	return sxy / sxx, true
+}
+
+// addFields records used as a new sample of an entity and adds growth_per_day and
+// days_until_full fields once there are enough samples. days_until_full is -1 if
+// usage is not growing.
+func (f *forecaster) addFields(
+	fields map[string]interface{},
+	key string,
+	t time.Time,
+	used, capacity float64,
+) {
+	f.add(key, t, used)
+	growth, ok := f.growthPerDay(key)
+	if !ok {
+		return
+	}
+	fields["growth_per_day"] = growth
+	fields["days_until_full"] = float64(-1)
+	if growth > 0 {
+		fields["days_until_full"] = max(capacity-used, 0) / growth
+	}
+}
+
+// forget drops the samples of entities not present in the given keys with the
+// given prefix
+func (f *forecaster) forget(prefix string, keys map[string]bool) {
+	for key := range f.series {
+		if strings.HasPrefix(key, prefix) && !keys[key] {
+			delete(f.series, key)
+		}
+	}
+}
+
+// save writes the samples to the state file
+func (f *forecaster) save() error {
+	var (
+		data []byte
+		tmp  *os.File
+		err  error
+	)
+
+	if data, err = json.Marshal(f.series); err != nil {
+		return err
+	}
+	if tmp, err = os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*"); err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
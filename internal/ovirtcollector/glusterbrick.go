@@ -0,0 +1,192 @@
+// This file contains ovirtcollector methods to gathers stats about gluster bricks
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectGlusterBrickInfo gathers oVirt gluster brick's info
+func (c *OVirtCollector) CollectGlusterBrickInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status                   ovirtsdk.GlusterBrickStatus
+		gvs                      *ovirtsdk.GlusterVolumeSlice
+		bricks                   *ovirtsdk.GlusterBrickSlice
+		brtags                   = make(map[string]string)
+		brfields                 = make(map[string]interface{})
+		clid, clname, dcname     string
+		gvid, gvname, id, server string
+		brickdir, device, fsname string
+		mntopts, srvid           string
+		t                        time.Time
+		ok                       bool
+		err                      error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get gluster bricks info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all gluster bricks entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, cl := range c.clusters.Slice() {
+		if clid, ok = cl.Id(); !ok {
+			acc.AddError(errors.New("found a cluster without Id, skipping"))
+			continue
+		}
+		if clname, ok = cl.Name(); !ok {
+			acc.AddError(errors.New("found a cluster without Name, skipping"))
+			continue
+		}
+		if !c.filterClusters.Match(clname) {
+			continue
+		}
+		if gvs, ok = cl.GlusterVolumes(); !ok {
+			continue
+		}
+		dcname = c.clusterDatacenterName(cl)
+		for _, gv := range gvs.Slice() {
+			if gvid, ok = gv.Id(); !ok {
+				acc.AddError(errors.New("found a gluster volume without Id, skipping"))
+				continue
+			}
+			gvname, _ = gv.Name()
+			if bricks, err = c.glusterVolumeBricks(clid, gvid); err != nil {
+				acc.AddError(
+					fmt.Errorf("could not get bricks for gluster volume %s: %w", gvname, err),
+				)
+				continue
+			}
+			for _, br := range bricks.Slice() {
+				if id, ok = br.Id(); !ok {
+					acc.AddError(errors.New("found a gluster brick without Id, skipping"))
+					continue
+				}
+				server = ""
+				if srvid, ok = br.ServerId(); ok {
+					server = c.hostNameFromID(srvid)
+				}
+				if !c.filterHosts.Match(server) {
+					continue
+				}
+				if status, ok = br.Status(); !ok {
+					status = ovirtsdk.GLUSTERBRICKSTATUS_UNKNOWN
+				}
+				brickdir, _ = br.BrickDir()
+				device, _ = br.Device()
+				fsname, _ = br.FsName()
+				mntopts, _ = br.MntOptions()
+
+				brtags["brick_dir"] = brickdir
+				brtags["clustername"] = clname
+				brtags["dcname"] = dcname
+				brtags["id"] = id
+				brtags["ovirt-engine"] = c.url.Host
+				brtags["server"] = server
+				brtags["volume"] = gvname
+
+				brfields["device"] = device
+				brfields["fs_name"] = fsname
+				brfields["mount_options"] = mntopts
+				brfields["status"] = string(status)
+				brfields["status_code"] = gbStatusCode(status)
+
+				acc.AddFields("ovirtstat_gluster_brick", brfields, brtags, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// glusterVolumeBricks returns the bricks of a gluster volume including their details
+func (c *OVirtCollector) glusterVolumeBricks(
+	clid, gvid string,
+) (*ovirtsdk.GlusterBrickSlice, error) {
+	var (
+		resp   *ovirtsdk.GlusterBricksServiceListResponse
+		bricks *ovirtsdk.GlusterBrickSlice
+		ok     bool
+		err    error
+	)
+
+	resp, err = c.conn.SystemService().ClustersService().ClusterService(clid).
+		GlusterVolumesService().VolumeService(gvid).
+		GlusterBricksService().List().Query("all_content", "true").Send()
+	if err != nil {
+		return nil, err
+	}
+	if bricks, ok = resp.Bricks(); !ok {
+		return nil, errors.New("could not get brick list or it is empty")
+	}
+	return bricks, nil
+}
+
+// glusterBricksStatus returns the number of up and down bricks of a gluster volume and
+// whether any brick included its status. Bricks with unknown status are not counted.
+func glusterBricksStatus(bricks *ovirtsdk.GlusterBrickSlice) (int, int, bool) {
+	var (
+		status     ovirtsdk.GlusterBrickStatus
+		up, down   int
+		withStatus bool
+		ok         bool
+	)
+
+	for _, br := range bricks.Slice() {
+		if status, ok = br.Status(); !ok {
+			continue
+		}
+		withStatus = true
+		switch status {
+		case ovirtsdk.GLUSTERBRICKSTATUS_UP:
+			up++
+		case ovirtsdk.GLUSTERBRICKSTATUS_DOWN:
+			down++
+		}
+	}
+	return up, down, withStatus
+}
+
+// gvIsRedundant returns true if the gluster volume type keeps redundant copies of data
+func gvIsRedundant(gvtype ovirtsdk.GlusterVolumeType) bool {
+	switch gvtype {
+	case ovirtsdk.GLUSTERVOLUMETYPE_DISPERSE,
+		ovirtsdk.GLUSTERVOLUMETYPE_DISTRIBUTED_DISPERSE,
+		ovirtsdk.GLUSTERVOLUMETYPE_DISTRIBUTED_REPLICATE,
+		ovirtsdk.GLUSTERVOLUMETYPE_DISTRIBUTED_STRIPED_REPLICATE,
+		ovirtsdk.GLUSTERVOLUMETYPE_REPLICATE,
+		ovirtsdk.GLUSTERVOLUMETYPE_STRIPED_REPLICATE:
+		return true
+	default:
+		return false
+	}
+}
+
+// gbStatusCode converts GlusterBrickStatus to int16 for easy alerting
+func gbStatusCode(status ovirtsdk.GlusterBrickStatus) int16 {
+	switch status {
+	case ovirtsdk.GLUSTERBRICKSTATUS_UP:
+		return 0
+	case ovirtsdk.GLUSTERBRICKSTATUS_UNKNOWN:
+		return 1
+	case ovirtsdk.GLUSTERBRICKSTATUS_DOWN:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -26,15 +26,17 @@ func (c *OVirtCollector) CollectGlusterVolumeInfo(
 		gvs                  *ovirtsdk.GlusterVolumeSlice
 		br                   *ovirtsdk.GlusterBrickSlice
 		cl                   *ovirtsdk.Cluster
+		stats                *ovirtsdk.StatisticSlice
 		gvtags               = make(map[string]string)
 		gvfields             = make(map[string]interface{})
+		gvstats              map[string]interface{}
 		id, name, dcname     string
-		clname               string
+		clid, clname         string
 		t                    time.Time
-		briks                int
+		briks, up, down      int
 		disperse, redundancy int64
 		replica, stripe      int64
-		ok                   bool
+		ok, withStatus       bool
 		err                  error
 	)
 
@@ -48,6 +50,7 @@ func (c *OVirtCollector) CollectGlusterVolumeInfo(
 	t = time.Now()
 
 	for _, cl = range c.clusters.Slice() {
+		clid, _ = cl.Id()
 		if clname, ok = cl.Name(); !ok {
 			acc.AddError(errors.New("found a cluster without Name, skipping"))
 			continue
@@ -74,10 +77,19 @@ func (c *OVirtCollector) CollectGlusterVolumeInfo(
 				acc.AddError(fmt.Errorf("could not get status for gluster volume %s", name))
 				continue
 			}
-			briks = 0
+			briks, up, down, withStatus = 0, 0, 0, false
 			if br, ok = gv.Bricks(); ok {
 				briks = len(br.Slice())
 			}
+			// volume inline bricks have no status, so list them with all_content
+			if br, err = c.glusterVolumeBricks(clid, id); err != nil {
+				acc.AddError(
+					fmt.Errorf("could not get bricks for gluster volume %s: %w", name, err),
+				)
+			} else {
+				briks = len(br.Slice())
+				up, down, withStatus = glusterBricksStatus(br)
+			}
 			disperse, _ = gv.DisperseCount()
 			redundancy, _ = gv.RedundancyCount()
 			replica, _ = gv.ReplicaCount()
@@ -91,6 +103,15 @@ func (c *OVirtCollector) CollectGlusterVolumeInfo(
 			gvtags["type"] = string(gvtype)
 
 			gvfields["briks"] = briks
+			if withStatus {
+				gvfields["bricks_down"] = down
+				gvfields["bricks_up"] = up
+				gvfields["degraded"] = gvIsRedundant(gvtype) && down > 0
+			} else {
+				delete(gvfields, "bricks_down")
+				delete(gvfields, "bricks_up")
+				delete(gvfields, "degraded")
+			}
 			gvfields["disperse_count"] = disperse
 			gvfields["redundancy_count"] = redundancy
 			gvfields["replica_count"] = replica
@@ -99,10 +120,29 @@ func (c *OVirtCollector) CollectGlusterVolumeInfo(
 			gvfields["stripe_count"] = stripe
 
 			acc.AddFields("ovirtstat_glustervolume", gvfields, gvtags, t)
+
+			if !c.gvStatistics {
+				continue
+			}
+			stats, err = listStatistics(
+				c.conn.SystemService().ClustersService().ClusterService(clid).
+					GlusterVolumesService().VolumeService(id).StatisticsService(),
+			)
+			if err != nil {
+				acc.AddError(
+					fmt.Errorf("could not get statistics for gluster volume %s: %w", name, err),
+				)
+				continue
+			}
+			gvstats = make(map[string]interface{})
+			addStatisticsFields(gvstats, stats)
+			if len(gvstats) > 0 {
+				acc.AddFields("ovirtstat_glustervolume_statistics", gvstats, gvtags, t)
+			}
 		}
 	}
 
-	return err
+	return nil
 }
 
 // gvStatusCode converts GlusterVolumeStatus to int16 for easy alerting
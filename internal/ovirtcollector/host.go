@@ -7,14 +7,23 @@ package ovirtcollector
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	ovirtsdk "github.com/ovirt/go-ovirt"
 	"github.com/tesibelda/lightmetric/metric"
+
+	"github.com/tesibelda/ovirtstat/internal/netplus"
 )
 
+// vdsmDefaultPort is the port VDSM listens on when the host does not report it
+const vdsmDefaultPort = 54321
+
 // CollectHostInfo gathers oVirt host's info
 func (c *OVirtCollector) CollectHostInfo(
 	ctx context.Context,
@@ -27,16 +36,22 @@ func (c *OVirtCollector) CollectHostInfo(
 		cpu                 *ovirtsdk.Cpu
 		cort                *ovirtsdk.CpuTopology
 		vmsumm              *ovirtsdk.VmSummary
+		os                  *ovirtsdk.OperatingSystem
+		ver                 *ovirtsdk.Version
 		hotags              = make(map[string]string)
 		hofields            = make(map[string]interface{})
 		id, name, dcname    string
-		clname              string
+		clname, ostype      string
+		osver, vdsmver      string
+		libvirtver          string
 		t                   time.Time
 		mem, cores          int64
 		sockets, threads    int64
 		vmact, vmmig, vmtot int64
 		speed               float64
+		certdays            int64
 		ok, reinstall       bool
+		update              bool
 		err                 error
 	)
 
@@ -61,11 +76,8 @@ func (c *OVirtCollector) CollectHostInfo(
 		if !c.filterHosts.Match(name) {
 			continue
 		}
-		if status, ok = host.Status(); !ok {
-			acc.AddError(fmt.Errorf("could not get status for host %s", name))
-			continue
-		}
-		htype, _ = host.Type()
+		// track hosts before the cluster filter so moving them does not emit events
+		c.hoStates.see(acc, c.url.Host, id, name, t)
 		clname, dcname = "", ""
 		if cl, ok = host.Cluster(); ok {
 			clname = c.clusterName(cl)
@@ -74,6 +86,11 @@ func (c *OVirtCollector) CollectHostInfo(
 			}
 			dcname = c.clusterDatacenterName(cl)
 		}
+		if status, ok = host.Status(); !ok {
+			acc.AddError(fmt.Errorf("could not get status for host %s", name))
+			continue
+		}
+		htype, _ = host.Type()
 		cores, sockets, speed, threads = 0, 0, 0, 0
 		if cpu, ok = host.Cpu(); ok {
 			if cort, ok = cpu.Topology(); ok {
@@ -93,6 +110,20 @@ func (c *OVirtCollector) CollectHostInfo(
 			vmmig, _ = vmsumm.Migrating()
 			vmtot, _ = vmsumm.Total()
 		}
+		ostype, osver, vdsmver, libvirtver = "", "", "", ""
+		if os, ok = host.Os(); ok {
+			ostype, _ = os.Type()
+			if ver, ok = os.Version(); ok {
+				osver, _ = ver.FullVersion()
+			}
+		}
+		if ver, ok = host.Version(); ok {
+			vdsmver, _ = ver.FullVersion()
+		}
+		if ver, ok = host.LibvirtVersion(); ok {
+			libvirtver, _ = ver.FullVersion()
+		}
+		update, _ = host.UpdateAvailable()
 
 		hotags["clustername"] = clname
 		hotags["dcname"] = dcname
@@ -105,20 +136,68 @@ func (c *OVirtCollector) CollectHostInfo(
 		hofields["cpu_sockets"] = sockets
 		hofields["cpu_speed"] = speed
 		hofields["cpu_threads"] = threads
+		hofields["libvirt_version"] = libvirtver
 		hofields["memory_size"] = mem
+		hofields["os_type"] = ostype
+		hofields["os_version"] = osver
 		hofields["reinstallation_required"] = reinstall
 		hofields["status"] = string(status)
 		hofields["status_code"] = hostStatusCode(status)
+		hofields["update_available"] = update
+		hofields["vdsm_version"] = vdsmver
 		hofields["vm_active"] = vmact
 		hofields["vm_migrating"] = vmmig
 		hofields["vm_total"] = vmtot
+		delete(hofields, "days_until_cert_expiry")
+		if c.hoCertificate && status == ovirtsdk.HOSTSTATUS_UP {
+			if certdays, err = c.hostCertificateDays(ctx, host, t); err != nil {
+				acc.AddError(fmt.Errorf("could not get certificate of host %s: %w", name, err))
+				err = nil
+			} else {
+				hofields["days_until_cert_expiry"] = certdays
+			}
+		}
 
 		acc.AddFields("ovirtstat_host", hofields, hotags, t)
+		c.hoStates.update(acc, c.url.Host, id, name, string(status), hostStatusCode(status), t)
 	}
+	c.hoStates.flush(acc, c.url.Host, t)
 
 	return err
 }
 
+// hostCertificateDays returns the days until the host VDSM certificate expires
+func (c *OVirtCollector) hostCertificateDays(
+	ctx context.Context,
+	host *ovirtsdk.Host,
+	t time.Time,
+) (int64, error) {
+	var (
+		certs   []*x509.Certificate
+		address string
+		port    int64
+		ok      bool
+		err     error
+	)
+
+	if address, ok = host.Address(); !ok {
+		return 0, errors.New("host without address")
+	}
+	if port, ok = host.Port(); !ok || port == 0 {
+		port = vdsmDefaultPort
+	}
+	certs, err = netplus.PeerCertificates(
+		ctx,
+		net.JoinHostPort(address, strconv.FormatInt(port, 10)),
+		c.timeout,
+		&tls.Config{InsecureSkipVerify: true}, //nolint:gosec // only expiry date is read
+	)
+	if err != nil {
+		return 0, err
+	}
+	return netplus.DaysUntil(certs[0].NotAfter, t), nil
+}
+
 // hostStatusCode converts HostStatus to int16 for easy alerting
 func hostStatusCode(status ovirtsdk.HostStatus) int16 {
 	var code int16
@@ -0,0 +1,213 @@
+// This file contains ovirtcollector methods to gathers stats about host devices
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// hostDeviceCapabilities are the device capabilities reported by HostDevices collector
+var hostDeviceCapabilities = map[string]bool{
+	"pci":        true,
+	"scsi":       true,
+	"usb_device": true,
+}
+
+// CollectHostDevicesInfo gathers oVirt host's PCI, USB and SCSI devices info including
+// their passthrough VM and mediated device types
+func (c *OVirtCollector) CollectHostDevicesInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		cl                       *ovirtsdk.Cluster
+		devices                  *ovirtsdk.HostDeviceSlice
+		product                  *ovirtsdk.Product
+		vendor                   *ovirtsdk.Vendor
+		vm                       *ovirtsdk.Vm
+		hdtags                   = make(map[string]string)
+		hdfields                 = make(map[string]interface{})
+		hoid, honame             string
+		clname, dcname           string
+		name, capability, driver string
+		prname, vdname, vmname   string
+		vmid                     string
+		t                        time.Time
+		iommu, vfs               int64
+		ok, placeholder          bool
+		err                      error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get host devices info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, host := range c.hosts.Slice() {
+		if hoid, ok = host.Id(); !ok {
+			acc.AddError(errors.New("found a host without Id, skipping"))
+			continue
+		}
+		if honame, ok = host.Name(); !ok {
+			acc.AddError(errors.New("found a host without Name, skipping"))
+			continue
+		}
+		if !c.filterHosts.Match(honame) {
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = host.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		if devices, err = c.hostDevices(hoid); err != nil {
+			acc.AddError(fmt.Errorf("could not get devices of host %s: %w", honame, err))
+			continue
+		}
+
+		for _, dev := range devices.Slice() {
+			if capability, ok = dev.Capability(); !ok || !hostDeviceCapabilities[capability] {
+				continue
+			}
+			if name, ok = dev.Name(); !ok {
+				acc.AddError(
+					fmt.Errorf("found a device without Name in host %s, skipping", honame),
+				)
+				continue
+			}
+			driver, _ = dev.Driver()
+			placeholder, _ = dev.Placeholder()
+			vfs, _ = dev.VirtualFunctions()
+			prname, vdname = "", ""
+			if product, ok = dev.Product(); ok {
+				prname = hostDeviceIDName(product.Id, product.Name)
+			}
+			if vendor, ok = dev.Vendor(); ok {
+				vdname = hostDeviceIDName(vendor.Id, vendor.Name)
+			}
+			vmid, vmname = "", ""
+			if vm, ok = dev.Vm(); ok {
+				vmid, _ = vm.Id()
+				if vmname, ok = vm.Name(); !ok {
+					vmname = c.vmNameFromID(vmid)
+				}
+			}
+
+			hdtags["capability"] = capability
+			hdtags["clustername"] = clname
+			hdtags["dcname"] = dcname
+			hdtags["hostname"] = honame
+			hdtags["name"] = name
+			hdtags["ovirt-engine"] = c.url.Host
+			hdtags["product"] = prname
+			hdtags["vendor"] = vdname
+
+			hdfields["driver"] = driver
+			delete(hdfields, "iommu_group")
+			if iommu, ok = dev.IommuGroup(); ok {
+				hdfields["iommu_group"] = iommu
+			}
+			hdfields["mdev_types"] = c.addHostDeviceMDevTypes(acc, hdtags, dev, t)
+			hdfields["passthrough"] = vmid != ""
+			hdfields["placeholder"] = placeholder
+			hdfields["virtual_functions"] = vfs
+			hdfields["vmname"] = vmname
+
+			acc.AddFields("ovirtstat_host_device", hdfields, hdtags, t)
+		}
+	}
+
+	return nil
+}
+
+// addHostDeviceMDevTypes adds an ovirtstat_host_mdev_type metric per mediated device
+// type supported by a host device and returns the number of types found
+func (c *OVirtCollector) addHostDeviceMDevTypes(
+	acc *metric.Accumulator,
+	hdtags map[string]string,
+	dev *ovirtsdk.HostDevice,
+	t time.Time,
+) int {
+	var (
+		mdevs     *ovirtsdk.MDevTypeSlice
+		name      string
+		hname     string
+		available int64
+		ok        bool
+	)
+
+	if mdevs, ok = dev.MDevTypes(); !ok {
+		return 0
+	}
+	for _, mdev := range mdevs.Slice() {
+		if name, ok = mdev.Name(); !ok {
+			continue
+		}
+		hname, _ = mdev.HumanReadableName()
+		available, _ = mdev.AvailableInstances()
+
+		acc.AddFields(
+			"ovirtstat_host_mdev_type",
+			map[string]interface{}{
+				"available_instances": available,
+				"description":         hname,
+			},
+			map[string]string{
+				"clustername":  hdtags["clustername"],
+				"dcname":       hdtags["dcname"],
+				"device":       hdtags["name"],
+				"hostname":     hdtags["hostname"],
+				"name":         name,
+				"ovirt-engine": c.url.Host,
+			},
+			t,
+		)
+	}
+	return len(mdevs.Slice())
+}
+
+// hostDevices returns the devices of a host
+func (c *OVirtCollector) hostDevices(id string) (*ovirtsdk.HostDeviceSlice, error) {
+	var (
+		resp    *ovirtsdk.HostDevicesServiceListResponse
+		devices *ovirtsdk.HostDeviceSlice
+		ok      bool
+		err     error
+	)
+
+	resp, err = c.conn.SystemService().HostsService().HostService(id).
+		DevicesService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if devices, ok = resp.Devices(); !ok {
+		return &ovirtsdk.HostDeviceSlice{}, nil
+	}
+	return devices, nil
+}
+
+// hostDeviceIDName returns the name of a device product or vendor, or its Id if it
+// has no name
+func hostDeviceIDName(id, name func() (string, bool)) string {
+	if n, ok := name(); ok && n != "" {
+		return n
+	}
+	n, _ := id()
+	return n
+}
@@ -0,0 +1,135 @@
+// This file contains ovirtcollector methods to gathers stats about hosted engine
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectHostedEngineInfo gathers oVirt hosted engine's info from HE capable hosts
+func (c *OVirtCollector) CollectHostedEngineInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		he                           *ovirtsdk.HostedEngine
+		cl                           *ovirtsdk.Cluster
+		hetags                       = make(map[string]string)
+		hefields                     = make(map[string]interface{})
+		id, name, dcname             string
+		clname, enginehost, vmstatus string
+		t                            time.Time
+		score                        int64
+		hehosts, scoredhosts         int
+		ok, configured, active       bool
+		globalmaint, localmaint      bool
+		err                          error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get hosted engine info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all hosted engine entity lists: %w", err)
+	}
+	t = time.Now()
+	enginehost, vmstatus = c.hostedEngineVMHost()
+
+	for _, host := range c.hosts.Slice() {
+		if id, ok = host.Id(); !ok {
+			acc.AddError(errors.New("found a host without Id, skipping"))
+			continue
+		}
+		if name, ok = host.Name(); !ok {
+			acc.AddError(errors.New("found a host without Name, skipping"))
+			continue
+		}
+		if !c.filterHosts.Match(name) {
+			continue
+		}
+		if he, ok = host.HostedEngine(); !ok {
+			continue
+		}
+		if configured, ok = he.Configured(); !ok || !configured {
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = host.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		active, _ = he.Active()
+		globalmaint, _ = he.GlobalMaintenance()
+		localmaint, _ = he.LocalMaintenance()
+		score, _ = he.Score()
+		hehosts++
+		if score > 0 {
+			scoredhosts++
+		}
+
+		hetags["clustername"] = clname
+		hetags["dcname"] = dcname
+		hetags["id"] = id
+		hetags["name"] = name
+		hetags["ovirt-engine"] = c.url.Host
+
+		hefields["active"] = active
+		hefields["configured"] = configured
+		hefields["engine_running"] = name == enginehost
+		hefields["global_maintenance"] = globalmaint
+		hefields["local_maintenance"] = localmaint
+		hefields["score"] = score
+
+		acc.AddFields("ovirtstat_hosted_engine", hefields, hetags, t)
+	}
+
+	acc.AddFields(
+		"ovirtstat_hosted_engine_summary",
+		map[string]interface{}{
+			"engine_host":      enginehost,
+			"engine_vm_status": vmstatus,
+			"hosts":            hehosts,
+			"hosts_with_score": scoredhosts,
+		},
+		map[string]string{"ovirt-engine": c.url.Host},
+		t,
+	)
+
+	return err
+}
+
+// hostedEngineVMHost returns the name of the host running the hosted engine VM and
+// the VM status from cache
+func (c *OVirtCollector) hostedEngineVMHost() (string, string) {
+	var (
+		status         ovirtsdk.VmStatus
+		ho             *ovirtsdk.Host
+		origin, honame string
+		ok             bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if origin, ok = vm.Origin(); !ok || origin != "managed_hosted_engine" {
+			continue
+		}
+		status, _ = vm.Status()
+		if ho, ok = vm.Host(); ok {
+			honame = c.hostName(ho)
+		}
+		return honame, string(status)
+	}
+	return "", ""
+}
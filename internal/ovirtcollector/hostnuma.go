@@ -0,0 +1,277 @@
+// This file contains ovirtcollector methods to gathers stats about host NUMA nodes
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// hugepagesPrefix is the prefix of host statistics about hugepages, which are named
+// like hugepages.<page size>.<counter>
+const hugepagesPrefix = "hugepages."
+
+// CollectHostNumaInfo gathers oVirt host's NUMA nodes and hugepages info. It also
+// gathers the host NUMA nodes VMs with vNUMA are pinned to.
+func (c *OVirtCollector) CollectHostNumaInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		cl                 *ovirtsdk.Cluster
+		nodes              *ovirtsdk.NumaNodeSlice
+		stats              *ovirtsdk.StatisticSlice
+		cpu                *ovirtsdk.Cpu
+		cores              *ovirtsdk.CoreSlice
+		nntags             = make(map[string]string)
+		nnfields           map[string]interface{}
+		hoid, honame       string
+		clname, dcname     string
+		t                  time.Time
+		index, mem, ncores int64
+		ok                 bool
+		err                error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get hosts NUMA info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all hosts entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, host := range c.hosts.Slice() {
+		if hoid, ok = host.Id(); !ok {
+			acc.AddError(errors.New("found a host without Id, skipping"))
+			continue
+		}
+		if honame, ok = host.Name(); !ok {
+			acc.AddError(errors.New("found a host without Name, skipping"))
+			continue
+		}
+		if !c.filterHosts.Match(honame) {
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = host.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		if nodes, err = c.hostNumaNodes(hoid); err != nil {
+			acc.AddError(fmt.Errorf("could not get NUMA nodes of host %s: %w", honame, err))
+			continue
+		}
+
+		nntags["clustername"] = clname
+		nntags["dcname"] = dcname
+		nntags["hostname"] = honame
+		nntags["ovirt-engine"] = c.url.Host
+
+		for _, node := range nodes.Slice() {
+			index, _ = node.Index()
+			mem, _ = node.Memory()
+			ncores = 0
+			if cpu, ok = node.Cpu(); ok {
+				if cores, ok = cpu.Cores(); ok {
+					ncores = int64(len(cores.Slice()))
+				}
+			}
+
+			nntags["index"] = strconv.FormatInt(index, 10)
+
+			nnfields = make(map[string]interface{})
+			if stats, ok = node.Statistics(); ok {
+				addStatisticsFields(nnfields, stats)
+			}
+			nnfields["cpu_cores"] = ncores
+			nnfields["memory_size"] = mem * 1024 * 1024
+
+			acc.AddFields("ovirtstat_host_numa_node", nnfields, nntags, t)
+		}
+
+		svc := c.conn.SystemService().HostsService().HostService(hoid).StatisticsService()
+		if stats, err = listStatistics(svc); err != nil {
+			acc.AddError(fmt.Errorf("could not get statistics of host %s: %w", honame, err))
+			continue
+		}
+		c.addHostHugepages(acc, nntags, stats, t)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	c.vmNumaPins = c.vmsNumaPinnedNodes(acc)
+
+	return nil
+}
+
+// addHostHugepages adds an ovirtstat_host_hugepages metric per page size found in the
+// host statistics
+func (c *OVirtCollector) addHostHugepages(
+	acc *metric.Accumulator,
+	nntags map[string]string,
+	stats *ovirtsdk.StatisticSlice,
+	t time.Time,
+) {
+	var (
+		pages      = make(map[string]map[string]interface{})
+		name, size string
+		counter    string
+		value      interface{}
+		found, ok  bool
+	)
+
+	for _, st := range stats.Slice() {
+		if name, ok = st.Name(); !ok || !strings.HasPrefix(name, hugepagesPrefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, hugepagesPrefix)
+		if size, counter, found = strings.Cut(name, "."); !found {
+			continue
+		}
+		if value, ok = statisticValue(st); !ok {
+			continue
+		}
+		if _, ok = pages[size]; !ok {
+			pages[size] = make(map[string]interface{})
+		}
+		pages[size][strings.ReplaceAll(counter, ".", "_")] = value
+	}
+
+	for size, fields := range pages {
+		acc.AddFields(
+			"ovirtstat_host_hugepages",
+			fields,
+			map[string]string{
+				"clustername":  nntags["clustername"],
+				"dcname":       nntags["dcname"],
+				"hostname":     nntags["hostname"],
+				"ovirt-engine": c.url.Host,
+				"page_size":    size,
+			},
+			t,
+		)
+	}
+}
+
+// hostNumaNodes returns the NUMA nodes of a host including their statistics
+func (c *OVirtCollector) hostNumaNodes(id string) (*ovirtsdk.NumaNodeSlice, error) {
+	var (
+		resp  *ovirtsdk.HostNumaNodesServiceListResponse
+		nodes *ovirtsdk.NumaNodeSlice
+		ok    bool
+		err   error
+	)
+
+	resp, err = c.conn.SystemService().HostsService().HostService(id).
+		NumaNodesService().List().Follow("statistics").Send()
+	if err != nil {
+		return nil, err
+	}
+	if nodes, ok = resp.Nodes(); !ok {
+		return &ovirtsdk.NumaNodeSlice{}, nil
+	}
+	return nodes, nil
+}
+
+// vmsNumaPinnedNodes returns the host NUMA nodes each VM Id is pinned to as a comma
+// separated list. Only VMs pinned to hosts are checked, as vNUMA pinning requires it.
+func (c *OVirtCollector) vmsNumaPinnedNodes(acc *metric.Accumulator) map[string]string {
+	var (
+		pp         *ovirtsdk.VmPlacementPolicy
+		hosts      *ovirtsdk.HostSlice
+		pins       = make(map[string]string)
+		nodes      []string
+		vmid, name string
+		ok         bool
+		err        error
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if vmid, ok = vm.Id(); !ok {
+			continue
+		}
+		if name, ok = vm.Name(); !ok || !c.filterVms.Match(name) {
+			continue
+		}
+		if pp, ok = vm.PlacementPolicy(); !ok {
+			continue
+		}
+		if hosts, ok = pp.Hosts(); !ok || len(hosts.Slice()) == 0 {
+			continue
+		}
+		if nodes, err = c.vmNumaPinnedNodes(vmid); err != nil {
+			acc.AddError(fmt.Errorf("could not get NUMA nodes of VM %s: %w", name, err))
+			continue
+		}
+		if len(nodes) > 0 {
+			pins[vmid] = strings.Join(nodes, ",")
+		}
+	}
+	return pins
+}
+
+// vmNumaPinnedNodes returns the sorted host NUMA node indexes a VM is pinned to
+func (c *OVirtCollector) vmNumaPinnedNodes(vmid string) ([]string, error) {
+	var (
+		resp    *ovirtsdk.VmNumaNodesServiceListResponse
+		vnodes  *ovirtsdk.VirtualNumaNodeSlice
+		npins   *ovirtsdk.NumaNodePinSlice
+		hnode   *ovirtsdk.NumaNode
+		seen    = make(map[int64]bool)
+		indexes []int
+		nodes   []string
+		index   int64
+		ok      bool
+		err     error
+	)
+
+	resp, err = c.conn.SystemService().VmsService().VmService(vmid).
+		NumaNodesService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if vnodes, ok = resp.Nodes(); !ok {
+		return nil, nil
+	}
+	for _, vnode := range vnodes.Slice() {
+		if npins, ok = vnode.NumaNodePins(); !ok {
+			continue
+		}
+		for _, pin := range npins.Slice() {
+			if index, ok = pin.Index(); !ok {
+				if hnode, ok = pin.HostNumaNode(); !ok {
+					continue
+				}
+				if index, ok = hnode.Index(); !ok {
+					continue
+				}
+			}
+			if !seen[index] {
+				seen[index] = true
+				indexes = append(indexes, int(index))
+			}
+		}
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		nodes = append(nodes, strconv.Itoa(i))
+	}
+	return nodes, nil
+}
@@ -0,0 +1,214 @@
+// This file contains ovirtcollector methods to gathers stats about host power management
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectHostPowerManagementInfo gathers oVirt host's power management and SPM info
+func (c *OVirtCollector) CollectHostPowerManagementInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		spmstatus          ovirtsdk.SpmStatus
+		cl                 *ovirtsdk.Cluster
+		pm                 *ovirtsdk.PowerManagement
+		spm                *ovirtsdk.Spm
+		agents             []*ovirtsdk.Agent
+		pmtags             = make(map[string]string)
+		pmfields           = make(map[string]interface{})
+		id, name, dcname   string
+		clname, fence      string
+		types, addresses   []string
+		t                  time.Time
+		priority           int64
+		ok, enabled, kdump bool
+		err                error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get hosts power management info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all hosts entity lists: %w", err)
+	}
+	// fence agents are kept as long as hosts cache, as they are listed per host
+	if !c.agentsUpdate.Equal(c.lastHoUpdate) {
+		c.fenceAgents = make(map[string][]*ovirtsdk.Agent)
+		c.agentsUpdate = c.lastHoUpdate
+	}
+	t = time.Now()
+
+	for _, host := range c.hosts.Slice() {
+		if id, ok = host.Id(); !ok {
+			acc.AddError(errors.New("found a host without Id, skipping"))
+			continue
+		}
+		if name, ok = host.Name(); !ok {
+			acc.AddError(errors.New("found a host without Name, skipping"))
+			continue
+		}
+		if !c.filterHosts.Match(name) {
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = host.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		enabled, kdump, agents = false, false, nil
+		if pm, ok = host.PowerManagement(); ok {
+			enabled, _ = pm.Enabled()
+			kdump, _ = pm.KdumpDetection()
+			if enabled {
+				if agents, err = c.hostFenceAgents(id, pm); err != nil {
+					acc.AddError(fmt.Errorf("could not get fence agents for host %s: %w", name, err))
+				}
+			}
+		}
+		types, addresses = agentsTypesAndAddresses(agents)
+		spmstatus, priority = ovirtsdk.SPMSTATUS_NONE, 0
+		if spm, ok = host.Spm(); ok {
+			if spmstatus, ok = spm.Status(); !ok {
+				spmstatus = ovirtsdk.SPMSTATUS_NONE
+			}
+			priority, _ = spm.Priority()
+		}
+
+		pmtags["clustername"] = clname
+		pmtags["dcname"] = dcname
+		pmtags["id"] = id
+		pmtags["name"] = name
+		pmtags["ovirt-engine"] = c.url.Host
+
+		pmfields = make(map[string]interface{})
+		pmfields["agents"] = len(agents)
+		pmfields["agent_types"] = strings.Join(types, ",")
+		if c.pmAddress {
+			pmfields["agent_addresses"] = strings.Join(addresses, ",")
+		}
+		pmfields["enabled"] = enabled
+		pmfields["kdump_detection"] = kdump
+		pmfields["spm"] = spmstatus == ovirtsdk.SPMSTATUS_SPM
+		pmfields["spm_priority"] = priority
+		pmfields["spm_status"] = string(spmstatus)
+		if c.fenceStatus && enabled {
+			if fence, err = c.hostFenceStatus(id); err != nil {
+				acc.AddError(fmt.Errorf("could not check fence status of host %s: %w", name, err))
+			} else {
+				pmfields["fence_status"] = fence
+				pmfields["fence_status_code"] = fenceStatusCode(ovirtsdk.PowerManagementStatus(fence))
+			}
+		}
+
+		acc.AddFields("ovirtstat_host_power_management", pmfields, pmtags, t)
+	}
+
+	return nil
+}
+
+// hostFenceAgents returns the fence agents of a host, querying the API if the host's
+// power management element does not include them and they are not cached yet
+func (c *OVirtCollector) hostFenceAgents(
+	id string,
+	pm *ovirtsdk.PowerManagement,
+) ([]*ovirtsdk.Agent, error) {
+	var (
+		agents *ovirtsdk.AgentSlice
+		cached []*ovirtsdk.Agent
+		resp   *ovirtsdk.FenceAgentsServiceListResponse
+		ok     bool
+		err    error
+	)
+
+	if agents, ok = pm.Agents(); ok && len(agents.Slice()) > 0 {
+		return agents.Slice(), nil
+	}
+	if cached, ok = c.fenceAgents[id]; ok {
+		return cached, nil
+	}
+	resp, err = c.conn.SystemService().HostsService().HostService(id).
+		FenceAgentsService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if agents, ok = resp.Agents(); ok {
+		cached = agents.Slice()
+	}
+	c.fenceAgents[id] = cached
+	return cached, nil
+}
+
+// hostFenceStatus returns the power management status of a host using the fence
+// status action
+func (c *OVirtCollector) hostFenceStatus(id string) (string, error) {
+	var (
+		status ovirtsdk.PowerManagementStatus
+		resp   *ovirtsdk.HostServiceFenceResponse
+		pm     *ovirtsdk.PowerManagement
+		ok     bool
+		err    error
+	)
+
+	resp, err = c.conn.SystemService().HostsService().HostService(id).
+		Fence().FenceType("status").Send()
+	if err != nil {
+		return "", err
+	}
+	if pm, ok = resp.PowerManagement(); !ok {
+		return string(ovirtsdk.POWERMANAGEMENTSTATUS_UNKNOWN), nil
+	}
+	if status, ok = pm.Status(); !ok {
+		return string(ovirtsdk.POWERMANAGEMENTSTATUS_UNKNOWN), nil
+	}
+	return string(status), nil
+}
+
+// agentsTypesAndAddresses returns fence agents types and addresses sorted by agent order
+func agentsTypesAndAddresses(agents []*ovirtsdk.Agent) ([]string, []string) {
+	var types, addresses []string
+
+	sort.SliceStable(agents, func(i, j int) bool {
+		oi, _ := agents[i].Order()
+		oj, _ := agents[j].Order()
+		return oi < oj
+	})
+	for _, ag := range agents {
+		atype, _ := ag.Type()
+		address, _ := ag.Address()
+		types = append(types, atype)
+		addresses = append(addresses, address)
+	}
+	return types, addresses
+}
+
+// fenceStatusCode converts PowerManagementStatus to int16 for easy alerting
+func fenceStatusCode(status ovirtsdk.PowerManagementStatus) int16 {
+	switch status {
+	case ovirtsdk.POWERMANAGEMENTSTATUS_ON:
+		return 0
+	case ovirtsdk.POWERMANAGEMENTSTATUS_OFF:
+		return 1
+	case ovirtsdk.POWERMANAGEMENTSTATUS_UNKNOWN:
+		return 2
+	default:
+		return 2
+	}
+}
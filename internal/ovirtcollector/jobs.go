@@ -0,0 +1,148 @@
+// This file contains ovirtcollector methods to gathers stats about engine jobs
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// jobStatuses are the job statuses counted in ovirtstat_jobs. Finished jobs are not
+// listed so they are not counted.
+var jobStatuses = []ovirtsdk.JobStatus{
+	ovirtsdk.JOBSTATUS_ABORTED,
+	ovirtsdk.JOBSTATUS_FAILED,
+	ovirtsdk.JOBSTATUS_STARTED,
+	ovirtsdk.JOBSTATUS_UNKNOWN,
+}
+
+// CollectJobsInfo gathers oVirt engine jobs info. Non-finished jobs are reported on
+// every collection while failed or aborted jobs are reported only once if they ended
+// after the collector was created.
+func (c *OVirtCollector) CollectJobsInfo(
+	_ context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status              ovirtsdk.JobStatus
+		resp                *ovirtsdk.JobsServiceListResponse
+		jobs                *ovirtsdk.JobSlice
+		steps               *ovirtsdk.StepSlice
+		jbtags              = make(map[string]string)
+		jbfields            map[string]interface{}
+		count               = make(map[ovirtsdk.JobStatus]int)
+		id, owner, desc     string
+		t, start, end       time.Time
+		nsteps              int
+		ok, cleared, extern bool
+		reported            bool
+		err                 error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get jobs info: %w", ErrorNoClient)
+	}
+
+	// jobs only include a reference to their owner, so follow it to get its name
+	resp, err = c.conn.SystemService().JobsService().List().
+		Search("status!=finished").Follow("steps,owner").Send()
+	if err != nil {
+		return fmt.Errorf("could not get job list: %w", err)
+	}
+	t = time.Now()
+	if jobs, ok = resp.Jobs(); !ok {
+		jobs = &ovirtsdk.JobSlice{}
+	}
+
+	for _, job := range jobs.Slice() {
+		if id, ok = job.Id(); !ok {
+			acc.AddError(errors.New("found a job without Id, skipping"))
+			continue
+		}
+		if status, ok = job.Status(); !ok {
+			status = ovirtsdk.JOBSTATUS_UNKNOWN
+		}
+		count[status]++
+		desc, _ = job.Description()
+		c.jbStates.see(acc, c.url.Host, id, desc, t)
+		reported = c.jbStates.status(id) == string(status)
+		c.jbStates.update(acc, c.url.Host, id, desc, string(status), jobStatusCode(status), t)
+		end, _ = job.EndTime()
+		if jobFailed(status) && (reported || !end.After(c.started)) {
+			continue
+		}
+		owner = jobOwner(job)
+		start, _ = job.StartTime()
+		cleared, _ = job.AutoCleared()
+		extern, _ = job.External()
+		nsteps = 0
+		if steps, ok = job.Steps(); ok {
+			nsteps = len(steps.Slice())
+		}
+
+		jbtags["id"] = id
+		jbtags["ovirt-engine"] = c.url.Host
+		jbtags["owner"] = owner
+
+		jbfields = map[string]interface{}{
+			"auto_cleared": cleared,
+			"description":  desc,
+			"external":     extern,
+			"start_time":   unixTime(start),
+			"status":       string(status),
+			"steps":        nsteps,
+		}
+		if jobFailed(status) {
+			jbfields["end_time"] = unixTime(end)
+			jbfields["duration_seconds"] = secondsSince(start, end)
+			acc.AddFields("ovirtstat_job_failure", jbfields, jbtags, t)
+			continue
+		}
+		jbfields["age_seconds"] = secondsSince(start, t)
+		acc.AddFields("ovirtstat_job", jbfields, jbtags, t)
+	}
+	c.jbStates.flush(acc, c.url.Host, t)
+
+	jbfields = make(map[string]interface{})
+	for _, st := range jobStatuses {
+		jbfields[string(st)] = count[st]
+	}
+	acc.AddFields("ovirtstat_jobs", jbfields, map[string]string{"ovirt-engine": c.url.Host}, t)
+
+	return nil
+}
+
+// jobOwner returns the user name of a job owner
+func jobOwner(job *ovirtsdk.Job) string {
+	if user, ok := job.Owner(); ok {
+		return userName(user)
+	}
+	return ""
+}
+
+// jobFailed returns true if the job status is a failed one
+func jobFailed(status ovirtsdk.JobStatus) bool {
+	return status == ovirtsdk.JOBSTATUS_FAILED || status == ovirtsdk.JOBSTATUS_ABORTED
+}
+
+// jobStatusCode converts JobStatus to int16 for easy alerting
+func jobStatusCode(status ovirtsdk.JobStatus) int16 {
+	switch status {
+	case ovirtsdk.JOBSTATUS_FINISHED, ovirtsdk.JOBSTATUS_STARTED:
+		return 0
+	case ovirtsdk.JOBSTATUS_ABORTED:
+		return 1
+	case ovirtsdk.JOBSTATUS_FAILED:
+		return 2
+	default:
+		return 1
+	}
+}
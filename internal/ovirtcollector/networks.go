@@ -0,0 +1,281 @@
+// This file contains ovirtcollector methods to gathers stats about logical networks
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectNetworksInfo gathers oVirt logical networks, their cluster attachments and
+// vNIC profiles info
+func (c *OVirtCollector) CollectNetworksInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		nets                   *ovirtsdk.NetworkSlice
+		dc                     *ovirtsdk.DataCenter
+		vlan                   *ovirtsdk.Vlan
+		nwtags                 = make(map[string]string)
+		nwfields               = make(map[string]interface{})
+		netnames               = make(map[string]string)
+		id, name, dcname, dcid string
+		t                      time.Time
+		vlanid, mtu            int64
+		ok, stp, required      bool
+		err                    error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get networks info: %w", ErrorNoClient)
+	}
+
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return fmt.Errorf("could not get all datacenter entity lists: %w", err)
+	}
+	if nets, err = c.listNetworks(); err != nil {
+		return fmt.Errorf("could not get network list: %w", err)
+	}
+	t = time.Now()
+
+	for _, nw := range nets.Slice() {
+		if id, ok = nw.Id(); !ok {
+			acc.AddError(errors.New("found a network without Id, skipping"))
+			continue
+		}
+		if name, ok = nw.Name(); !ok {
+			acc.AddError(errors.New("found a network without Name, skipping"))
+			continue
+		}
+		netnames[id] = name
+		dcname = ""
+		if dc, ok = nw.DataCenter(); ok {
+			if dcid, ok = dc.Id(); ok {
+				dcname = c.datacenterNameFromID(dcid)
+			}
+		}
+		vlanid = 0
+		if vlan, ok = nw.Vlan(); ok {
+			vlanid, _ = vlan.Id()
+		}
+		mtu, _ = nw.Mtu()
+		stp, _ = nw.Stp()
+		required, _ = nw.Required()
+
+		nwtags["dcname"] = dcname
+		nwtags["id"] = id
+		nwtags["name"] = name
+		nwtags["ovirt-engine"] = c.url.Host
+
+		nwfields["mtu"] = mtu
+		nwfields["required"] = required
+		nwfields["stp"] = stp
+		nwfields["usages"] = networkUsages(nw)
+		nwfields["vlan_id"] = vlanid
+		nwfields["vm_network"] = networkHasUsage(nw, ovirtsdk.NETWORKUSAGE_VM)
+
+		acc.AddFields("ovirtstat_network", nwfields, nwtags, t)
+	}
+
+	c.collectClusterNetworks(acc, t)
+	if err = c.collectVnicProfiles(acc, netnames, t); err != nil {
+		return fmt.Errorf("could not get vNIC profiles info: %w", err)
+	}
+
+	return nil
+}
+
+// collectClusterNetworks adds networks attachment info per cluster to the accumulator
+func (c *OVirtCollector) collectClusterNetworks(acc *metric.Accumulator, t time.Time) {
+	var (
+		status           ovirtsdk.NetworkStatus
+		resp             *ovirtsdk.ClusterNetworksServiceListResponse
+		nets             *ovirtsdk.NetworkSlice
+		cntags           = make(map[string]string)
+		cnfields         = make(map[string]interface{})
+		clid, clname, id string
+		name, dcname     string
+		ok, required     bool
+		err              error
+	)
+
+	for _, cl := range c.clusters.Slice() {
+		if clid, ok = cl.Id(); !ok {
+			acc.AddError(errors.New("found a cluster without Id, skipping"))
+			continue
+		}
+		if clname, ok = cl.Name(); !ok {
+			acc.AddError(errors.New("found a cluster without Name, skipping"))
+			continue
+		}
+		if !c.filterClusters.Match(clname) {
+			continue
+		}
+		resp, err = c.conn.SystemService().ClustersService().ClusterService(clid).
+			NetworksService().List().Send()
+		if err != nil {
+			acc.AddError(fmt.Errorf("could not get networks for cluster %s: %w", clname, err))
+			continue
+		}
+		if nets, ok = resp.Networks(); !ok {
+			continue
+		}
+		dcname = c.clusterDatacenterName(cl)
+		for _, nw := range nets.Slice() {
+			if id, ok = nw.Id(); !ok {
+				acc.AddError(errors.New("found a network without Id, skipping"))
+				continue
+			}
+			name, _ = nw.Name()
+			status, _ = nw.Status()
+			required, _ = nw.Required()
+
+			cntags["clustername"] = clname
+			cntags["dcname"] = dcname
+			cntags["id"] = id
+			cntags["name"] = name
+			cntags["ovirt-engine"] = c.url.Host
+
+			cnfields["required"] = required
+			cnfields["status"] = string(status)
+			cnfields["status_code"] = networkStatusCode(status)
+			cnfields["usage_display"] = networkHasUsage(nw, ovirtsdk.NETWORKUSAGE_DISPLAY)
+			cnfields["usage_gluster"] = networkHasUsage(nw, ovirtsdk.NETWORKUSAGE_GLUSTER)
+			cnfields["usage_management"] = networkHasUsage(nw, ovirtsdk.NETWORKUSAGE_MANAGEMENT)
+			cnfields["usage_migration"] = networkHasUsage(nw, ovirtsdk.NETWORKUSAGE_MIGRATION)
+			cnfields["usages"] = networkUsages(nw)
+
+			acc.AddFields("ovirtstat_cluster_network", cnfields, cntags, t)
+		}
+	}
+}
+
+// collectVnicProfiles adds vNIC profiles info to the accumulator
+func (c *OVirtCollector) collectVnicProfiles(
+	acc *metric.Accumulator,
+	netnames map[string]string,
+	t time.Time,
+) error {
+	var (
+		ptmode               ovirtsdk.VnicPassThroughMode
+		resp                 *ovirtsdk.VnicProfilesServiceListResponse
+		profiles             *ovirtsdk.VnicProfileSlice
+		nw                   *ovirtsdk.Network
+		qos                  *ovirtsdk.Qos
+		pt                   *ovirtsdk.VnicPassThrough
+		vptags               = make(map[string]string)
+		vpfields             = make(map[string]interface{})
+		id, name, netid      string
+		qosname              string
+		ok, mirroring, migra bool
+		err                  error
+	)
+
+	// profiles only include a reference to their QoS, so follow it to get its name
+	resp, err = c.conn.SystemService().VnicProfilesService().List().Follow("qos").Send()
+	if err != nil {
+		return err
+	}
+	if profiles, ok = resp.Profiles(); !ok {
+		return nil
+	}
+	for _, vp := range profiles.Slice() {
+		if id, ok = vp.Id(); !ok {
+			acc.AddError(errors.New("found a vNIC profile without Id, skipping"))
+			continue
+		}
+		name, _ = vp.Name()
+		netid = ""
+		if nw, ok = vp.Network(); ok {
+			netid, _ = nw.Id()
+		}
+		qosname = ""
+		if qos, ok = vp.Qos(); ok {
+			qosname, _ = qos.Name()
+		}
+		ptmode = ovirtsdk.VNICPASSTHROUGHMODE_DISABLED
+		if pt, ok = vp.PassThrough(); ok {
+			if ptmode, ok = pt.Mode(); !ok {
+				ptmode = ovirtsdk.VNICPASSTHROUGHMODE_DISABLED
+			}
+		}
+		mirroring, _ = vp.PortMirroring()
+		migra, _ = vp.Migratable()
+
+		vptags["id"] = id
+		vptags["name"] = name
+		vptags["network"] = netnames[netid]
+		vptags["ovirt-engine"] = c.url.Host
+
+		vpfields["migratable"] = migra
+		vpfields["pass_through"] = ptmode == ovirtsdk.VNICPASSTHROUGHMODE_ENABLED
+		vpfields["port_mirroring"] = mirroring
+		vpfields["qos"] = qosname
+
+		acc.AddFields("ovirtstat_vnic_profile", vpfields, vptags, t)
+	}
+
+	return nil
+}
+
+// listNetworks returns all logical networks
+func (c *OVirtCollector) listNetworks() (*ovirtsdk.NetworkSlice, error) {
+	var (
+		resp *ovirtsdk.NetworksServiceListResponse
+		nets *ovirtsdk.NetworkSlice
+		ok   bool
+		err  error
+	)
+
+	if resp, err = c.conn.SystemService().NetworksService().List().Send(); err != nil {
+		return nil, err
+	}
+	if nets, ok = resp.Networks(); !ok {
+		return nil, errors.New("could not get network list or it is empty")
+	}
+	return nets, nil
+}
+
+// networkUsages returns a network usages as a comma separated string
+func networkUsages(nw *ovirtsdk.Network) string {
+	var usages []string
+
+	nus, _ := nw.Usages()
+	for _, nu := range nus {
+		usages = append(usages, string(nu))
+	}
+	return strings.Join(usages, ",")
+}
+
+// networkHasUsage returns true if the network has the given usage
+func networkHasUsage(nw *ovirtsdk.Network, usage ovirtsdk.NetworkUsage) bool {
+	nus, _ := nw.Usages()
+	for _, nu := range nus {
+		if nu == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// networkStatusCode converts NetworkStatus to int16 for easy alerting
+func networkStatusCode(status ovirtsdk.NetworkStatus) int16 {
+	switch status {
+	case ovirtsdk.NETWORKSTATUS_OPERATIONAL:
+		return 0
+	case ovirtsdk.NETWORKSTATUS_NON_OPERATIONAL:
+		return 1
+	default:
+		return 1
+	}
+}
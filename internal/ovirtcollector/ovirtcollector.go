@@ -11,7 +11,10 @@ package ovirtcollector
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -23,6 +26,7 @@ import (
 
 // Common raised errors
 var (
+	ErrorNoCache  = errors.New("no cache data duration can be set for collector")
 	ErrorNoClient = errors.New("no oVirt connection has been opened")
 	ErrorNotVC    = errors.New("endpoint does not look like an oVirt Engine")
 	ErrorURLNil   = errors.New("oVirt Engine URL should not be nil")
@@ -34,10 +38,38 @@ type OVirtCollector struct {
 	urlString, user, pass string
 	url                   *url.URL
 	conn                  *ovirtsdk.Connection
+	filterDatacenters     filter.Filter
 	filterClusters        filter.Filter
 	filterHosts           filter.Filter
 	filterVms             filter.Filter
-	dataDuration          time.Duration
+	dcStates              *stateTracker
+	hoStates              *stateTracker
+	sdStates              *stateTracker
+	vmStates              *stateTracker
+	jbStates              *stateTracker
+	itStates              *stateTracker
+	raStates              *stateTracker
+	fenceStatus           bool
+	pmAddress             bool
+	gvStatistics          bool
+	hoCertificate         bool
+	engineCerts           bool
+	pkiCA                 bool
+	certs                 []*x509.Certificate
+	certsErr              error
+	certsTime             time.Time
+	pkiClient             *http.Client
+	vmNumaPins            map[string]string
+	lunPaths              map[string]int64
+	sessionsSeen          map[string]time.Time
+	fenceAgents           map[string][]*ovirtsdk.Agent
+	agentsUpdate          time.Time
+	spPolicies            map[string]*ovirtsdk.SchedulingPolicy
+	spUnits               map[string]string
+	spUpdate              time.Time
+	forecast              *forecaster
+	timeout               time.Duration
+	started               time.Time
 	VcCache
 }
 
@@ -50,11 +82,22 @@ func New(
 	var err error
 
 	ovc := OVirtCollector{
-		urlString:    ovirtURL,
-		user:         user,
-		pass:         pass,
-		conn:         nil,
-		dataDuration: dataDuration,
+		urlString: ovirtURL,
+		user:      user,
+		pass:      pass,
+		conn:      nil,
+		dcStates:  newStateTracker("datacenter", false),
+		hoStates:  newStateTracker("host", true),
+		sdStates:  newStateTracker("storagedomain", false),
+		vmStates:  newStateTracker("vm", true),
+		jbStates:  newStateTracker("job", false),
+		itStates:  newStateTracker("image_transfer", false),
+		raStates:  newStateTracker("role_assignment", true),
+		started:   time.Now(),
+	}
+	ovc.SetDataDuration(dataDuration)
+	if err = ovc.SetFilterDatacenters(nil, nil); err != nil {
+		return nil, err
 	}
 	if err = ovc.SetFilterClusters(nil, nil); err != nil {
 		return nil, err
@@ -76,9 +119,142 @@ func New(
 	return &ovc, err
 }
 
-// SetDataDuration sets max cache data duration
+// SetDataDuration sets max cache data duration for all entities
 func (c *OVirtCollector) SetDataDuration(du time.Duration) {
-	c.dataDuration = du
+	c.dcDuration = du
+	c.hoDuration = du
+	c.sdDuration = du
+	c.vmDuration = du
+	c.tpDuration = du
+	c.poDuration = du
+}
+
+// SetCollectorDataDuration sets max cache data duration for the entities of the given
+// collector. GlusterVolumes collector shares Datacenters cache so it is not accepted here.
+func (c *OVirtCollector) SetCollectorDataDuration(collector string, du time.Duration) error {
+	switch collector {
+	case "Datacenters":
+		c.dcDuration = du
+	case "Hosts":
+		c.hoDuration = du
+	case "StorageDomains":
+		c.sdDuration = du
+	case "Templates":
+		c.tpDuration = du
+	case "VMs":
+		c.vmDuration = du
+	case "VMPools":
+		c.poDuration = du
+	default:
+		return fmt.Errorf("%w: %s", ErrorNoCache, collector)
+	}
+	return nil
+}
+
+// SetHostsAllContent sets if hosts should be listed with all their content, which is
+// needed to get some host elements like hosted_engine
+func (c *OVirtCollector) SetHostsAllContent(all bool) {
+	c.hoAllContent = all
+}
+
+// SetFenceStatus sets if hosts power management status should be checked using the
+// fence status action
+func (c *OVirtCollector) SetFenceStatus(check bool) {
+	c.fenceStatus = check
+}
+
+// SetPMAgentAddress sets if hosts fence agents addresses should be reported
+func (c *OVirtCollector) SetPMAgentAddress(show bool) {
+	c.pmAddress = show
+}
+
+// SetGlusterVolumeStatistics sets if gluster volumes statistics should be gathered
+func (c *OVirtCollector) SetGlusterVolumeStatistics(gather bool) {
+	c.gvStatistics = gather
+}
+
+// SetHostsCertificateCheck sets if hosts VDSM certificate should be checked connecting
+// to each host
+func (c *OVirtCollector) SetHostsCertificateCheck(check bool) {
+	c.hoCertificate = check
+}
+
+// AddHostsFollow adds a link to be followed when listing hosts, so that the linked
+// elements like statistics are included in hosts cache
+func (c *OVirtCollector) AddHostsFollow(link string) {
+	if !c.hostsFollow(link) {
+		c.hoFollows = append(c.hoFollows, link)
+	}
+}
+
+// hostsFollow returns true if the given link is followed when listing hosts
+func (c *OVirtCollector) hostsFollow(link string) bool {
+	for _, f := range c.hoFollows {
+		if f == link {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTemplatesFollow adds a link to be followed when listing templates, so that the
+// linked elements like disk_attachments are included in templates cache
+func (c *OVirtCollector) AddTemplatesFollow(link string) {
+	if !c.templatesFollow(link) {
+		c.tpFollows = append(c.tpFollows, link)
+	}
+}
+
+// templatesFollow returns true if the given link is followed when listing templates
+func (c *OVirtCollector) templatesFollow(link string) bool {
+	for _, f := range c.tpFollows {
+		if f == link {
+			return true
+		}
+	}
+	return false
+}
+
+// AddVmsFollow adds a link to be followed when listing VMs, so that the linked elements
+// like reported_devices are included in VMs cache
+func (c *OVirtCollector) AddVmsFollow(link string) {
+	if !c.vmsFollow(link) {
+		c.vmFollows = append(c.vmFollows, link)
+	}
+}
+
+// vmsFollow returns true if the given link is followed when listing VMs
+func (c *OVirtCollector) vmsFollow(link string) bool {
+	for _, f := range c.vmFollows {
+		if f == link {
+			return true
+		}
+	}
+	return false
+}
+
+// SetForecast enables capacity forecasting of clusters and storagedomains keeping a
+// rolling window of samples in the given state file. An empty path disables it.
+func (c *OVirtCollector) SetForecast(path string, window time.Duration) error {
+	var err error
+
+	c.forecast = nil
+	if path == "" {
+		return nil
+	}
+	c.forecast, err = newForecaster(path, window)
+	return err
+}
+
+// SetFilterDatacenters sets datacenters include and exclude filters
+func (c *OVirtCollector) SetFilterDatacenters(include, exclude []string) error {
+	var err error
+
+	c.filterDatacenters, err = filter.NewIncludeExcludeFilter(include, exclude)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
 // SetFilterClusters sets clusters include and exclude filters
@@ -114,10 +290,19 @@ func (c *OVirtCollector) SetFilterVms(include, exclude []string) error {
 	return nil
 }
 
+// SetEngineCertificates sets if the engine certificate chain should be captured when
+// opening a session and periodically after that, and if the engine's PKI CA certificate
+// should be checked too
+func (c *OVirtCollector) SetEngineCertificates(capture, pkiCA bool) {
+	c.engineCerts = capture
+	c.pkiCA = pkiCA
+}
+
 // Open opens a OVirt connection session
-func (c *OVirtCollector) Open(_ context.Context, timeout time.Duration) error {
+func (c *OVirtCollector) Open(ctx context.Context, timeout time.Duration) error {
 	var err error
 
+	c.timeout = timeout
 	c.conn, err = ovirtsdk.NewConnectionBuilder().
 		URL(c.urlString).
 		Username(c.user).
@@ -126,6 +311,9 @@ func (c *OVirtCollector) Open(_ context.Context, timeout time.Duration) error {
 		Compress(true).
 		Timeout(timeout).
 		Build()
+	if err == nil && c.engineCerts {
+		c.captureCertificates(ctx)
+	}
 
 	return err
 }
@@ -143,4 +331,24 @@ func (c *OVirtCollector) Close() {
 	if c.conn != nil {
 		c.conn.Close()
 	}
+	if c.pkiClient != nil {
+		c.pkiClient.CloseIdleConnections()
+		c.pkiClient = nil
+	}
+}
+
+// unixTime returns a date as unix time in seconds, or 0 if it is not set
+func unixTime(date time.Time) int64 {
+	if date.IsZero() {
+		return 0
+	}
+	return date.Unix()
+}
+
+// secondsSince returns the seconds elapsed from date to t, or 0 if date is not set
+func secondsSince(date, t time.Time) int64 {
+	if date.IsZero() {
+		return 0
+	}
+	return int64(t.Sub(date).Seconds())
 }
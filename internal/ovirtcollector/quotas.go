@@ -0,0 +1,223 @@
+// This file contains ovirtcollector methods to gathers stats about datacenter quotas
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// quotaUnlimited is the value oVirt uses for unlimited quota limits
+const quotaUnlimited = -1
+
+// CollectQuotasInfo gathers oVirt datacenter quotas limits and usage
+func (c *OVirtCollector) CollectQuotasInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		quotas             *ovirtsdk.QuotaSlice
+		cl                 *ovirtsdk.Cluster
+		sd                 *ovirtsdk.StorageDomain
+		qttags             map[string]string
+		id, name, dcname   string
+		dcid, qtname, qtid string
+		t                  time.Time
+		grace, threshold   int64
+		ok                 bool
+		err                error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get quotas info: %w", ErrorNoClient)
+	}
+
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return fmt.Errorf("could not get all datacenter entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersStorageDomains(ctx); err != nil {
+		return fmt.Errorf("could not get all storagedomain entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, dc := range c.dcs.Slice() {
+		if dcid, ok = dc.Id(); !ok {
+			acc.AddError(errors.New("found a datacenter without Id, skipping"))
+			continue
+		}
+		if dcname, ok = dc.Name(); !ok {
+			acc.AddError(errors.New("found a datacenter without Name, skipping"))
+			continue
+		}
+		if !c.filterDatacenters.Match(dcname) {
+			continue
+		}
+		if quotas, err = c.datacenterQuotas(dcid); err != nil {
+			acc.AddError(fmt.Errorf("could not get quotas for datacenter %s: %w", dcname, err))
+			continue
+		}
+		for _, qt := range quotas.Slice() {
+			if qtid, ok = qt.Id(); !ok {
+				acc.AddError(errors.New("found a quota without Id, skipping"))
+				continue
+			}
+			qtname, _ = qt.Name()
+
+			grace, _ = qt.ClusterHardLimitPct()
+			threshold, _ = qt.ClusterSoftLimitPct()
+			for _, cll := range quotaClusterLimits(qt) {
+				id, name = "", ""
+				if cl, ok = cll.Cluster(); ok {
+					id, _ = cl.Id()
+					name = c.clusterName(cl)
+					if !c.filterClusters.Match(name) {
+						continue
+					}
+				}
+				qttags = c.quotaTags(dcname, qtid, qtname, "cluster", id, name)
+				acc.AddFields("ovirtstat_quota", quotaClusterFields(cll, grace, threshold), qttags, t)
+			}
+
+			grace, _ = qt.StorageHardLimitPct()
+			threshold, _ = qt.StorageSoftLimitPct()
+			for _, sdl := range quotaStorageLimits(qt) {
+				id, name = "", ""
+				if sd, ok = sdl.StorageDomain(); ok {
+					id, _ = sd.Id()
+					name = c.storageDomainName(sd)
+				}
+				qttags = c.quotaTags(dcname, qtid, qtname, "storagedomain", id, name)
+				acc.AddFields("ovirtstat_quota", quotaStorageFields(sdl, grace, threshold), qttags, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// datacenterQuotas returns the quotas of a datacenter including their limits
+func (c *OVirtCollector) datacenterQuotas(id string) (*ovirtsdk.QuotaSlice, error) {
+	var (
+		resp   *ovirtsdk.QuotasServiceListResponse
+		quotas *ovirtsdk.QuotaSlice
+		ok     bool
+		err    error
+	)
+
+	resp, err = c.conn.SystemService().DataCentersService().DataCenterService(id).
+		QuotasService().List().Follow("quotaclusterlimits,quotastoragelimits").Send()
+	if err != nil {
+		return nil, err
+	}
+	if quotas, ok = resp.Quotas(); !ok {
+		return &ovirtsdk.QuotaSlice{}, nil
+	}
+	return quotas, nil
+}
+
+// quotaTags returns the tags of a quota limit. Limits without scope entity apply to
+// all entities of the datacenter.
+func (c *OVirtCollector) quotaTags(
+	dcname, id, name, scope, scopeid, scopename string,
+) map[string]string {
+	return map[string]string{
+		"dcname":       dcname,
+		"id":           id,
+		"name":         name,
+		"ovirt-engine": c.url.Host,
+		"scope":        scope,
+		"scope_id":     scopeid,
+		"scope_name":   scopename,
+	}
+}
+
+// quotaClusterLimits returns the cluster limits of a quota
+func quotaClusterLimits(qt *ovirtsdk.Quota) []*ovirtsdk.QuotaClusterLimit {
+	if limits, ok := qt.QuotaClusterLimits(); ok {
+		return limits.Slice()
+	}
+	return nil
+}
+
+// quotaStorageLimits returns the storage limits of a quota
+func quotaStorageLimits(qt *ovirtsdk.Quota) []*ovirtsdk.QuotaStorageLimit {
+	if limits, ok := qt.QuotaStorageLimits(); ok {
+		return limits.Slice()
+	}
+	return nil
+}
+
+// quotaClusterFields returns the fields of a quota cluster limit
+func quotaClusterFields(
+	cll *ovirtsdk.QuotaClusterLimit,
+	grace, threshold int64,
+) map[string]interface{} {
+	var (
+		memlimit, memusage   float64
+		vcpulimit, vcpuusage int64
+		mempct, vcpupct      float64
+	)
+
+	memlimit, _ = cll.MemoryLimit()
+	memusage, _ = cll.MemoryUsage()
+	vcpulimit, _ = cll.VcpuLimit()
+	vcpuusage, _ = cll.VcpuUsage()
+	mempct = quotaPercent(memusage, memlimit)
+	vcpupct = quotaPercent(float64(vcpuusage), float64(vcpulimit))
+
+	return map[string]interface{}{
+		"grace_pct":     grace,
+		"memory_limit":  gibToBytes(memlimit),
+		"memory_usage":  gibToBytes(memusage),
+		"percent_used":  max(mempct, vcpupct),
+		"threshold_pct": threshold,
+		"vcpu_limit":    vcpulimit,
+		"vcpu_usage":    vcpuusage,
+	}
+}
+
+// quotaStorageFields returns the fields of a quota storage limit
+func quotaStorageFields(
+	sdl *ovirtsdk.QuotaStorageLimit,
+	grace, threshold int64,
+) map[string]interface{} {
+	var (
+		limit int64
+		usage float64
+	)
+
+	limit, _ = sdl.Limit()
+	usage, _ = sdl.Usage()
+
+	return map[string]interface{}{
+		"grace_pct":     grace,
+		"percent_used":  quotaPercent(usage, float64(limit)),
+		"storage_limit": gibToBytes(float64(limit)),
+		"storage_usage": gibToBytes(usage),
+		"threshold_pct": threshold,
+	}
+}
+
+// quotaPercent returns usage as a percentage of limit, 0 if limit is unlimited
+func quotaPercent(usage, limit float64) float64 {
+	if limit == quotaUnlimited || limit <= 0 {
+		return 0
+	}
+	return usage * 100 / limit
+}
+
+// gibToBytes converts a quota value in GiB to bytes keeping unlimited value as is
+func gibToBytes(gib float64) int64 {
+	if gib == quotaUnlimited {
+		return quotaUnlimited
+	}
+	return int64(gib * (1 << 30))
+}
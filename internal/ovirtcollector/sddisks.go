@@ -0,0 +1,308 @@
+// This file contains ovirtcollector methods to gathers stats about storagedomain disks
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// sdDiskStatuses are the disk statuses counted per storagedomain
+var sdDiskStatuses = []ovirtsdk.DiskStatus{
+	ovirtsdk.DISKSTATUS_ILLEGAL,
+	ovirtsdk.DISKSTATUS_LOCKED,
+	ovirtsdk.DISKSTATUS_OK,
+}
+
+// sdDiskContents are the disk content groups counted per storagedomain
+var sdDiskContents = []string{"data", "hosted_engine", "iso", "memory_dump", "other", "ovf_store"}
+
+// CollectStorageDomainDisksInfo gathers oVirt storagedomain's disks inventory
+func (c *OVirtCollector) CollectStorageDomainDisksInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status                 ovirtsdk.DiskStatus
+		ctype                  ovirtsdk.DiskContentType
+		sdty                   ovirtsdk.StorageDomainType
+		disks                  *ovirtsdk.DiskSlice
+		sdtags                 = make(map[string]string)
+		sdfields               = make(map[string]interface{})
+		attached               map[string]string
+		tpdisks                map[string]bool
+		count, sizes           map[string]int64
+		id, name, diskid       string
+		content, vmname        string
+		t                      time.Time
+		actual                 int64
+		total, totalsize       int64
+		floating, floatingsize int64
+		ok                     bool
+		err                    error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get storagedomain disks info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersStorageDomains(ctx); err != nil {
+		return fmt.Errorf("could not get all storagedomain entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	if err = c.getAllTemplates(ctx); err != nil {
+		return fmt.Errorf("could not get all template entity lists: %w", err)
+	}
+	t = time.Now()
+	attached = c.attachedDisks()
+	tpdisks = c.templateDisks()
+
+	for _, sd := range c.sds.Slice() {
+		if id, ok = sd.Id(); !ok {
+			acc.AddError(errors.New("found a storagedomain without Id, skipping"))
+			continue
+		}
+		if name, ok = sd.Name(); !ok {
+			acc.AddError(fmt.Errorf("found a storagedomain %s without Name, skipping", id))
+			continue
+		}
+		if sdty, ok = sd.Type(); !ok ||
+			(sdty != ovirtsdk.STORAGEDOMAINTYPE_DATA && sdty != ovirtsdk.STORAGEDOMAINTYPE_ISO) {
+			continue
+		}
+		if disks, err = c.storageDomainDisks(id); err != nil {
+			acc.AddError(fmt.Errorf("could not get disks for storagedomain %s: %w", name, err))
+			continue
+		}
+
+		count = make(map[string]int64)
+		sizes = make(map[string]int64)
+		total, totalsize, floating, floatingsize = 0, 0, 0, 0
+		for _, disk := range disks.Slice() {
+			if diskid, ok = disk.Id(); !ok {
+				continue
+			}
+			if status, ok = disk.Status(); !ok {
+				status = ovirtsdk.DISKSTATUS_OK
+			}
+			ctype, _ = disk.ContentType()
+			content = diskContentGroup(ctype)
+			actual, _ = disk.ActualSize()
+			vmname = attached[diskid]
+
+			total++
+			totalsize += actual
+			count[string(status)]++
+			sizes[string(status)] += actual
+			count[content]++
+			sizes[content] += actual
+			if content == "data" && vmname == "" && !tpdisks[diskid] && !diskHasOwner(disk) {
+				floating++
+				floatingsize += actual
+			}
+
+			if status != ovirtsdk.DISKSTATUS_OK {
+				c.addIllegalDisk(acc, disk, name, vmname, string(ctype), t)
+			}
+		}
+
+		sdtags["id"] = id
+		sdtags["name"] = name
+		sdtags["ovirt-engine"] = c.url.Host
+		sdtags["type"] = string(sdty)
+
+		sdfields["disks"] = total
+		sdfields["disks_size"] = totalsize
+		for _, st := range sdDiskStatuses {
+			sdfields["disks_"+string(st)] = count[string(st)]
+			sdfields["disks_"+string(st)+"_size"] = sizes[string(st)]
+		}
+		for _, ct := range sdDiskContents {
+			sdfields["content_"+ct] = count[ct]
+			sdfields["content_"+ct+"_size"] = sizes[ct]
+		}
+		sdfields["floating"] = floating
+		sdfields["floating_size"] = floatingsize
+
+		acc.AddFields("ovirtstat_storagedomain_disks", sdfields, sdtags, t)
+	}
+
+	return nil
+}
+
+// addIllegalDisk adds an ovirtstat_disk_illegal metric for a disk in illegal or locked
+// status
+func (c *OVirtCollector) addIllegalDisk(
+	acc *metric.Accumulator,
+	disk *ovirtsdk.Disk,
+	sdname, vmname, ctype string,
+	t time.Time,
+) {
+	var (
+		status              ovirtsdk.DiskStatus
+		id, alias           string
+		actual, provisioned int64
+	)
+
+	id, _ = disk.Id()
+	alias, _ = disk.Alias()
+	status, _ = disk.Status()
+	actual, _ = disk.ActualSize()
+	provisioned, _ = disk.ProvisionedSize()
+
+	acc.AddFields(
+		"ovirtstat_disk_illegal",
+		map[string]interface{}{
+			"actual_size":      actual,
+			"provisioned_size": provisioned,
+			"status":           string(status),
+			"status_code":      diskStatusCode(status),
+		},
+		map[string]string{
+			"content_type":  ctype,
+			"id":            id,
+			"name":          alias,
+			"ovirt-engine":  c.url.Host,
+			"storagedomain": sdname,
+			"vmname":        vmname,
+		},
+		t,
+	)
+}
+
+// storageDomainDisks returns the disks of a storagedomain
+func (c *OVirtCollector) storageDomainDisks(id string) (*ovirtsdk.DiskSlice, error) {
+	var (
+		resp  *ovirtsdk.StorageDomainDisksServiceListResponse
+		disks *ovirtsdk.DiskSlice
+		ok    bool
+		err   error
+	)
+
+	resp, err = c.conn.SystemService().StorageDomainsService().StorageDomainService(id).
+		DisksService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if disks, ok = resp.Disks(); !ok {
+		return &ovirtsdk.DiskSlice{}, nil
+	}
+	return disks, nil
+}
+
+// attachedDisks returns the name of the VM each disk Id is attached to from cache
+func (c *OVirtCollector) attachedDisks() map[string]string {
+	var (
+		das            *ovirtsdk.DiskAttachmentSlice
+		disk           *ovirtsdk.Disk
+		vmname, diskid string
+		attached       = make(map[string]string)
+		ok             bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if das, ok = vm.DiskAttachments(); !ok {
+			continue
+		}
+		vmname, _ = vm.Name()
+		for _, da := range das.Slice() {
+			if disk, ok = da.Disk(); ok {
+				if diskid, ok = disk.Id(); ok {
+					attached[diskid] = vmname
+				}
+			}
+		}
+	}
+	return attached
+}
+
+// templateDisks returns the disk Ids attached to templates from cache
+func (c *OVirtCollector) templateDisks() map[string]bool {
+	var (
+		das    *ovirtsdk.DiskAttachmentSlice
+		disk   *ovirtsdk.Disk
+		diskid string
+		disks  = make(map[string]bool)
+		ok     bool
+	)
+
+	if !c.templatesFollow("disk_attachments") {
+		return disks
+	}
+	for _, tp := range c.tps.Slice() {
+		if das, ok = tp.DiskAttachments(); !ok {
+			continue
+		}
+		for _, da := range das.Slice() {
+			if disk, ok = da.Disk(); ok {
+				if diskid, ok = disk.Id(); ok {
+					disks[diskid] = true
+				}
+			}
+		}
+	}
+	return disks
+}
+
+// diskHasOwner returns true if the disk references a VM or template
+func diskHasOwner(disk *ovirtsdk.Disk) bool {
+	var vms *ovirtsdk.VmSlice
+	var ok bool
+
+	if _, ok = disk.Template(); ok {
+		return true
+	}
+	if _, ok = disk.Vm(); ok {
+		return true
+	}
+	if vms, ok = disk.Vms(); ok && len(vms.Slice()) > 0 {
+		return true
+	}
+	return false
+}
+
+// diskContentGroup returns the content group used to count a disk given its content type
+func diskContentGroup(ctype ovirtsdk.DiskContentType) string {
+	switch ctype {
+	case ovirtsdk.DISKCONTENTTYPE_DATA, "":
+		return "data"
+	case ovirtsdk.DISKCONTENTTYPE_OVF_STORE:
+		return "ovf_store"
+	case ovirtsdk.DISKCONTENTTYPE_MEMORY_DUMP_VOLUME,
+		ovirtsdk.DISKCONTENTTYPE_MEMORY_METADATA_VOLUME:
+		return "memory_dump"
+	case ovirtsdk.DISKCONTENTTYPE_ISO:
+		return "iso"
+	case ovirtsdk.DISKCONTENTTYPE_HOSTED_ENGINE,
+		ovirtsdk.DISKCONTENTTYPE_HOSTED_ENGINE_CONFIGURATION,
+		ovirtsdk.DISKCONTENTTYPE_HOSTED_ENGINE_METADATA,
+		ovirtsdk.DISKCONTENTTYPE_HOSTED_ENGINE_SANLOCK:
+		return "hosted_engine"
+	default:
+		return "other"
+	}
+}
+
+// diskStatusCode converts DiskStatus to int16 for easy alerting
+func diskStatusCode(status ovirtsdk.DiskStatus) int16 {
+	switch status {
+	case ovirtsdk.DISKSTATUS_OK:
+		return 0
+	case ovirtsdk.DISKSTATUS_LOCKED:
+		return 1
+	case ovirtsdk.DISKSTATUS_ILLEGAL:
+		return 2
+	default:
+		return 1
+	}
+}
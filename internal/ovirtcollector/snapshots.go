@@ -0,0 +1,163 @@
+// This file contains ovirtcollector methods to gathers stats about VM snapshots
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectSnapshotsInfo gathers oVirt VM's snapshots info
+func (c *OVirtCollector) CollectSnapshotsInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		stype                ovirtsdk.SnapshotType
+		status               ovirtsdk.SnapshotStatus
+		cl                   *ovirtsdk.Cluster
+		ho                   *ovirtsdk.Host
+		snaps                *ovirtsdk.SnapshotSlice
+		disks                *ovirtsdk.DiskSlice
+		sntags               = make(map[string]string)
+		snfields             = make(map[string]interface{})
+		vmid, vmname, dcname string
+		clname, hostname     string
+		id, description      string
+		t, date              time.Time
+		actual, provisioned  int64
+		ok, persistmem       bool
+		err                  error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get VM snapshots info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, vm := range c.vms.Slice() {
+		if vmid, ok = vm.Id(); !ok {
+			acc.AddError(errors.New("found a VM without Id, skipping"))
+			continue
+		}
+		if vmname, ok = vm.Name(); !ok {
+			acc.AddError(errors.New("found a VM without Name, skipping"))
+			continue
+		}
+		if !c.filterVms.Match(vmname) {
+			continue
+		}
+		hostname = ""
+		if ho, ok = vm.Host(); ok {
+			hostname = c.hostName(ho)
+			if !c.filterHosts.Match(hostname) {
+				continue
+			}
+		}
+		clname, dcname = "", ""
+		if cl, ok = vm.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		if snaps, ok = vm.Snapshots(); !ok {
+			continue
+		}
+		for _, snap := range snaps.Slice() {
+			if stype, ok = snap.SnapshotType(); ok && stype == ovirtsdk.SNAPSHOTTYPE_ACTIVE {
+				continue
+			}
+			if id, ok = snap.Id(); !ok {
+				acc.AddError(fmt.Errorf("found a snapshot without Id in VM %s, skipping", vmname))
+				continue
+			}
+			status, _ = snap.SnapshotStatus()
+			description, _ = snap.Description()
+			persistmem, _ = snap.PersistMemorystate()
+			date, _ = snap.Date()
+			if disks, ok = snap.Disks(); !ok {
+				disks = &ovirtsdk.DiskSlice{}
+			}
+			actual, provisioned = disksSizes(disks)
+
+			sntags["clustername"] = clname
+			sntags["dcname"] = dcname
+			sntags["id"] = id
+			sntags["ovirt-engine"] = c.url.Host
+			sntags["type"] = string(stype)
+			sntags["vm_id"] = vmid
+			sntags["vmname"] = vmname
+
+			snfields["actual_size"] = actual
+			snfields["age_seconds"] = secondsSince(date, t)
+			snfields["date"] = unixTime(date)
+			snfields["description"] = description
+			snfields["disks"] = len(disks.Slice())
+			snfields["persist_memorystate"] = persistmem
+			snfields["provisioned_size"] = provisioned
+			snfields["status"] = string(status)
+
+			acc.AddFields("ovirtstat_vm_snapshot", snfields, sntags, t)
+		}
+	}
+
+	return nil
+}
+
+// vmSnapshotsSummary returns the number of non active snapshots of a VM and the age in
+// seconds of the oldest one
+func vmSnapshotsSummary(vm *ovirtsdk.Vm, t time.Time) (int, int64) {
+	var (
+		stype  ovirtsdk.SnapshotType
+		snaps  *ovirtsdk.SnapshotSlice
+		date   time.Time
+		count  int
+		oldest int64
+		ok     bool
+	)
+
+	if snaps, ok = vm.Snapshots(); !ok {
+		return 0, 0
+	}
+	for _, snap := range snaps.Slice() {
+		if stype, ok = snap.SnapshotType(); ok && stype == ovirtsdk.SNAPSHOTTYPE_ACTIVE {
+			continue
+		}
+		count++
+		if date, ok = snap.Date(); ok {
+			if age := secondsSince(date, t); age > oldest {
+				oldest = age
+			}
+		}
+	}
+	return count, oldest
+}
+
+// disksSizes returns the sum of actual and provisioned sizes of the given disks
+func disksSizes(disks *ovirtsdk.DiskSlice) (int64, int64) {
+	var actual, provisioned int64
+
+	for _, disk := range disks.Slice() {
+		if size, ok := disk.ActualSize(); ok {
+			actual += size
+		}
+		if size, ok := disk.ProvisionedSize(); ok {
+			provisioned += size
+		}
+	}
+	return actual, provisioned
+}
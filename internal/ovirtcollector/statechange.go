@@ -0,0 +1,151 @@
+// This file contains ovirtcollector methods to track entity status changes between
+// collections
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"time"
+
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// entityState contains the last known status of an entity
+type entityState struct {
+	status string
+	code   int16
+	since  time.Time
+	first  time.Time
+}
+
+// stateTracker keeps the last known status of entities of a kind across collections
+type stateTracker struct {
+	entity  string
+	events  bool
+	names   map[string]string
+	known   map[string]bool
+	present map[string]bool
+	states  map[string]*entityState
+}
+
+// newStateTracker returns a stateTracker for the given entity kind. If events is true
+// entity appearance and disappearance events will be added too.
+func newStateTracker(entity string, events bool) *stateTracker {
+	return &stateTracker{
+		entity:  entity,
+		events:  events,
+		names:   make(map[string]string),
+		present: make(map[string]bool),
+		states:  make(map[string]*entityState),
+	}
+}
+
+// see marks an entity as present in current collection, adding an appearance event if
+// it was not present in the previous one
+func (st *stateTracker) see(
+	acc *metric.Accumulator,
+	engine, id, name string,
+	t time.Time,
+) {
+	st.present[id] = true
+	st.names[id] = name
+	if st.events && st.known != nil && !st.known[id] {
+		st.addEvent(acc, engine, id, name, "created", t)
+	}
+}
+
+// status returns the last known status of an entity or empty string if unknown
+func (st *stateTracker) status(id string) string {
+	if state, ok := st.states[id]; ok {
+		return state.status
+	}
+	return ""
+}
+
+// age returns the seconds since an entity status was first recorded
+func (st *stateTracker) age(id string, t time.Time) int64 {
+	if state, ok := st.states[id]; ok {
+		return int64(t.Sub(state.first).Seconds())
+	}
+	return 0
+}
+
+// update records an entity status adding a status change metric if it has changed
+// since the previous collection
+func (st *stateTracker) update(
+	acc *metric.Accumulator,
+	engine, id, name, status string,
+	code int16,
+	t time.Time,
+) {
+	var (
+		state  *entityState
+		tags   map[string]string
+		fields map[string]interface{}
+		ok     bool
+	)
+
+	if state, ok = st.states[id]; !ok {
+		st.states[id] = &entityState{status: status, code: code, since: t, first: t}
+		return
+	}
+	if state.status == status {
+		return
+	}
+
+	tags = map[string]string{
+		"entity":       st.entity,
+		"id":           id,
+		"name":         name,
+		"ovirt-engine": engine,
+	}
+	fields = map[string]interface{}{
+		"duration_seconds": int64(t.Sub(state.since).Seconds()),
+		"new_status":       status,
+		"new_status_code":  code,
+		"old_status":       state.status,
+		"old_status_code":  state.code,
+	}
+	acc.AddFields("ovirtstat_status_change", fields, tags, t)
+
+	state.status = status
+	state.code = code
+	state.since = t
+}
+
+// flush ends current collection adding disappearance events for entities that were
+// present in the previous collection but not in this one
+func (st *stateTracker) flush(acc *metric.Accumulator, engine string, t time.Time) {
+	for id := range st.known {
+		if st.present[id] {
+			continue
+		}
+		if st.events {
+			st.addEvent(acc, engine, id, st.names[id], "removed", t)
+		}
+		delete(st.names, id)
+		delete(st.states, id)
+	}
+	st.known = st.present
+	st.present = make(map[string]bool)
+}
+
+// addEvent adds an entity event metric to the accumulator
+func (st *stateTracker) addEvent(
+	acc *metric.Accumulator,
+	engine, id, name, event string,
+	t time.Time,
+) {
+	tags := map[string]string{
+		"entity":       st.entity,
+		"id":           id,
+		"name":         name,
+		"ovirt-engine": engine,
+	}
+	fields := map[string]interface{}{
+		"event": event,
+	}
+	acc.AddFields("ovirtstat_entity_event", fields, tags, t)
+}
@@ -0,0 +1,77 @@
+// This file contains ovirtcollector helpers to convert oVirt statistics into metric fields
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"errors"
+	"strings"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+)
+
+// listStatistics returns the statistics of the given statistics service
+func listStatistics(svc *ovirtsdk.StatisticsService) (*ovirtsdk.StatisticSlice, error) {
+	var (
+		resp  *ovirtsdk.StatisticsServiceListResponse
+		stats *ovirtsdk.StatisticSlice
+		ok    bool
+		err   error
+	)
+
+	if resp, err = svc.List().Send(); err != nil {
+		return nil, err
+	}
+	if stats, ok = resp.Statistics(); !ok {
+		return nil, errors.New("could not get statistics list or it is empty")
+	}
+	return stats, nil
+}
+
+// addStatisticsFields adds each statistic to fields using its name with underscores
+// as field name, e.g. memory.total.size becomes memory_total_size
+func addStatisticsFields(fields map[string]interface{}, stats *ovirtsdk.StatisticSlice) {
+	var (
+		name  string
+		value interface{}
+		ok    bool
+	)
+
+	for _, st := range stats.Slice() {
+		if name, ok = st.Name(); !ok {
+			continue
+		}
+		if value, ok = statisticValue(st); !ok {
+			continue
+		}
+		fields[strings.ReplaceAll(name, ".", "_")] = value
+	}
+}
+
+// statisticValue returns the first value of a statistic according to its type
+func statisticValue(st *ovirtsdk.Statistic) (interface{}, bool) {
+	var (
+		vtype  ovirtsdk.ValueType
+		values *ovirtsdk.ValueSlice
+		datum  float64
+		ok     bool
+	)
+
+	if values, ok = st.Values(); !ok || len(values.Slice()) == 0 {
+		return nil, false
+	}
+	vtype, _ = st.Type()
+	switch vtype {
+	case ovirtsdk.VALUETYPE_STRING:
+		return values.Slice()[0].Detail()
+	case ovirtsdk.VALUETYPE_INTEGER:
+		if datum, ok = values.Slice()[0].Datum(); !ok {
+			return nil, false
+		}
+		return int64(datum), true
+	default:
+		return values.Slice()[0].Datum()
+	}
+}
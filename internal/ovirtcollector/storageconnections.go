@@ -0,0 +1,320 @@
+// This file contains ovirtcollector methods to gathers stats about storage connections
+// and host LUN paths
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// hostLun contains a logical unit seen by a host
+type hostLun struct {
+	lun              *ovirtsdk.LogicalUnit
+	stype            ovirtsdk.StorageType
+	hostname, clname string
+	dcname, key      string
+}
+
+// CollectStorageConnectionsInfo gathers oVirt storage connections, host iSCSI sessions
+// and host LUN paths info
+func (c *OVirtCollector) CollectStorageConnectionsInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status         ovirtsdk.HostStatus
+		cl             *ovirtsdk.Cluster
+		storages       *ovirtsdk.HostStorageSlice
+		luns           []hostLun
+		listed         = make(map[string]bool)
+		hoid, honame   string
+		clname, dcname string
+		t              time.Time
+		ok             bool
+		err            error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get storage connections info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersStorageDomains(ctx); err != nil {
+		return fmt.Errorf("could not get all storagedomain entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all hosts entity lists: %w", err)
+	}
+	if err = c.collectStorageConnections(acc); err != nil {
+		return fmt.Errorf("could not get storage connection list: %w", err)
+	}
+	t = time.Now()
+
+	for _, host := range c.hosts.Slice() {
+		if hoid, ok = host.Id(); !ok {
+			acc.AddError(errors.New("found a host without Id, skipping"))
+			continue
+		}
+		if honame, ok = host.Name(); !ok {
+			acc.AddError(errors.New("found a host without Name, skipping"))
+			continue
+		}
+		if !c.filterHosts.Match(honame) {
+			continue
+		}
+		if status, ok = host.Status(); !ok || status != ovirtsdk.HOSTSTATUS_UP {
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = host.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		if storages, err = c.hostStorages(hoid); err != nil {
+			acc.AddError(fmt.Errorf("could not get storage of host %s: %w", honame, err))
+			continue
+		}
+		listed[hoid] = true
+		c.addHostIscsiSessions(acc, storages, honame, clname, dcname, t)
+		luns = append(luns, hostLuns(storages, hoid, honame, clname, dcname)...)
+	}
+	c.addHostLuns(acc, luns, listed, t)
+
+	return nil
+}
+
+// collectStorageConnections adds storage connections info to the accumulator
+func (c *OVirtCollector) collectStorageConnections(acc *metric.Accumulator) error {
+	var (
+		stype                ovirtsdk.StorageType
+		resp                 *ovirtsdk.StorageServerConnectionsServiceListResponse
+		conns                *ovirtsdk.StorageConnectionSlice
+		sctags               = make(map[string]string)
+		scfields             = make(map[string]interface{})
+		id, address          string
+		target, portal, path string
+		t                    time.Time
+		port                 int64
+		ok                   bool
+		err                  error
+	)
+
+	if resp, err = c.conn.SystemService().StorageConnectionsService().List().Send(); err != nil {
+		return err
+	}
+	t = time.Now()
+	if conns, ok = resp.Connections(); !ok {
+		return nil
+	}
+	for _, sc := range conns.Slice() {
+		if id, ok = sc.Id(); !ok {
+			acc.AddError(errors.New("found a storage connection without Id, skipping"))
+			continue
+		}
+		stype, _ = sc.Type()
+		address, _ = sc.Address()
+		port, _ = sc.Port()
+		target, _ = sc.Target()
+		portal, _ = sc.Portal()
+		path, _ = sc.Path()
+
+		sctags["address"] = address
+		sctags["id"] = id
+		sctags["ovirt-engine"] = c.url.Host
+		sctags["type"] = string(stype)
+
+		scfields["path"] = path
+		scfields["port"] = port
+		scfields["portal"] = portal
+		scfields["target"] = target
+
+		acc.AddFields("ovirtstat_storage_connection", scfields, sctags, t)
+	}
+
+	return nil
+}
+
+// addHostIscsiSessions adds an ovirtstat_host_iscsi metric with the number of iSCSI
+// sessions of a host, one per target and portal of its iSCSI logical units
+func (c *OVirtCollector) addHostIscsiSessions(
+	acc *metric.Accumulator,
+	storages *ovirtsdk.HostStorageSlice,
+	honame, clname, dcname string,
+	t time.Time,
+) {
+	var (
+		stype          ovirtsdk.StorageType
+		luns           *ovirtsdk.LogicalUnitSlice
+		sessions       = make(map[string]bool)
+		targets        = make(map[string]bool)
+		target, portal string
+		ok             bool
+	)
+
+	for _, hs := range storages.Slice() {
+		if stype, ok = hs.Type(); !ok || stype != ovirtsdk.STORAGETYPE_ISCSI {
+			continue
+		}
+		if luns, ok = hs.LogicalUnits(); !ok {
+			continue
+		}
+		for _, lun := range luns.Slice() {
+			target, _ = lun.Target()
+			portal, _ = lun.Portal()
+			if portal == "" {
+				portal, _ = lun.Address()
+			}
+			targets[target] = true
+			sessions[target+"/"+portal] = true
+		}
+	}
+	if len(sessions) == 0 {
+		return
+	}
+
+	acc.AddFields(
+		"ovirtstat_host_iscsi",
+		map[string]interface{}{
+			"sessions": len(sessions),
+			"targets":  len(targets),
+		},
+		map[string]string{
+			"clustername":  clname,
+			"dcname":       dcname,
+			"hostname":     honame,
+			"ovirt-engine": c.url.Host,
+		},
+		t,
+	)
+}
+
+// addHostLuns adds an ovirtstat_host_lun metric per host logical unit. The API only
+// reports the number of paths of a LUN, so its total number of paths is the highest
+// seen for that LUN in the same host since ovirtstat started. LUNs are only forgotten
+// when they are no longer seen by a host whose storage was listed.
+func (c *OVirtCollector) addHostLuns(
+	acc *metric.Accumulator,
+	luns []hostLun,
+	listed map[string]bool,
+	t time.Time,
+) {
+	var (
+		status       ovirtsdk.LunStatus
+		lutags       = make(map[string]string)
+		lufields     = make(map[string]interface{})
+		seen         = make(map[string]bool)
+		id, sdid     string
+		vendor, prod string
+		paths, total int64
+		size         int64
+	)
+
+	if c.lunPaths == nil {
+		c.lunPaths = make(map[string]int64)
+	}
+	for _, hl := range luns {
+		id, _ = hl.lun.Id()
+		paths, _ = hl.lun.Paths()
+		total = max(paths, c.lunPaths[hl.key])
+		c.lunPaths[hl.key] = total
+		seen[hl.key] = true
+		status, _ = hl.lun.Status()
+		sdid, _ = hl.lun.StorageDomainId()
+		vendor, _ = hl.lun.VendorId()
+		prod, _ = hl.lun.ProductId()
+		size, _ = hl.lun.Size()
+
+		lutags["clustername"] = hl.clname
+		lutags["dcname"] = hl.dcname
+		lutags["hostname"] = hl.hostname
+		lutags["id"] = id
+		lutags["ovirt-engine"] = c.url.Host
+		lutags["storagedomain"] = c.storageDomainNameFromID(sdid)
+		lutags["type"] = string(hl.stype)
+
+		lufields["degraded"] = paths < total
+		lufields["paths"] = paths
+		lufields["paths_total"] = total
+		lufields["product_id"] = prod
+		lufields["size"] = size
+		lufields["status"] = string(status)
+		lufields["vendor_id"] = vendor
+
+		acc.AddFields("ovirtstat_host_lun", lufields, lutags, t)
+	}
+	for key := range c.lunPaths {
+		if hoid, _, _ := strings.Cut(key, "/"); listed[hoid] && !seen[key] {
+			delete(c.lunPaths, key)
+		}
+	}
+}
+
+// hostStorages returns the storage seen by a host
+func (c *OVirtCollector) hostStorages(id string) (*ovirtsdk.HostStorageSlice, error) {
+	var (
+		resp     *ovirtsdk.HostStorageServiceListResponse
+		storages *ovirtsdk.HostStorageSlice
+		ok       bool
+		err      error
+	)
+
+	resp, err = c.conn.SystemService().HostsService().HostService(id).
+		StorageService().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	if storages, ok = resp.Storages(); !ok {
+		return &ovirtsdk.HostStorageSlice{}, nil
+	}
+	return storages, nil
+}
+
+// hostLuns returns the FC and iSCSI logical units of a host storage list
+func hostLuns(
+	storages *ovirtsdk.HostStorageSlice,
+	hoid, honame, clname, dcname string,
+) []hostLun {
+	var (
+		stype ovirtsdk.StorageType
+		luns  *ovirtsdk.LogicalUnitSlice
+		id    string
+		hls   []hostLun
+		ok    bool
+	)
+
+	for _, hs := range storages.Slice() {
+		if stype, ok = hs.Type(); !ok ||
+			(stype != ovirtsdk.STORAGETYPE_ISCSI && stype != ovirtsdk.STORAGETYPE_FCP) {
+			continue
+		}
+		if luns, ok = hs.LogicalUnits(); !ok {
+			continue
+		}
+		for _, lun := range luns.Slice() {
+			if id, ok = lun.Id(); !ok {
+				continue
+			}
+			hls = append(hls, hostLun{
+				lun:      lun,
+				stype:    stype,
+				hostname: honame,
+				clname:   clname,
+				dcname:   dcname,
+				key:      hoid + "/" + id,
+			})
+		}
+	}
+	return hls
+}
@@ -27,6 +27,7 @@ func (c *OVirtCollector) CollectDatastoresInfo(
 		conns                      *ovirtsdk.StorageConnectionSlice
 		sdtags                     = make(map[string]string)
 		sdfields                   = make(map[string]interface{})
+		sdkeys                     = make(map[string]bool)
 		id, name, sdtype, stype    string
 		t                          time.Time
 		available, committed, used int64
@@ -53,6 +54,7 @@ func (c *OVirtCollector) CollectDatastoresInfo(
 			acc.AddError(fmt.Errorf("found a storagedomain %s without Name, skipping", id))
 			continue
 		}
+		c.sdStates.see(acc, c.url.Host, id, name, t)
 		status, _ = sd.Status() //nolint: external storage may return !ok
 		sdtype = ""
 		if sdty, ok = sd.Type(); ok {
@@ -98,8 +100,27 @@ func (c *OVirtCollector) CollectDatastoresInfo(
 		sdfields["status"] = string(status)
 		sdfields["status_code"] = storagedomainStatusCode(status)
 		sdfields["used"] = used
+		delete(sdfields, "days_until_full")
+		delete(sdfields, "growth_per_day")
+		if c.forecast != nil && status != ovirtsdk.STORAGEDOMAINSTATUS_UNATTACHED {
+			sdkeys["storagedomain/"+id] = true
+			c.forecast.addFields(
+				sdfields, "storagedomain/"+id, t, float64(used), float64(used+available),
+			)
+		}
 
 		acc.AddFields("ovirtstat_storagedomain", sdfields, sdtags, t)
+		c.sdStates.update(
+			acc, c.url.Host, id, name, string(status), storagedomainStatusCode(status), t,
+		)
+	}
+	c.sdStates.flush(acc, c.url.Host, t)
+	if c.forecast != nil {
+		c.forecast.forget("storagedomain/", sdkeys)
+		if err = c.forecast.save(); err != nil {
+			acc.AddError(fmt.Errorf("could not save forecast state: %w", err))
+			err = nil
+		}
 	}
 
 	return err
@@ -0,0 +1,246 @@
+// This file contains ovirtcollector methods to gathers stats about templates and
+// instance types
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectTemplatesInfo gathers oVirt templates and instance types info
+func (c *OVirtCollector) CollectTemplatesInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status                  ovirtsdk.TemplateStatus
+		cl                      *ovirtsdk.Cluster
+		cpu                     *ovirtsdk.Cpu
+		cort                    *ovirtsdk.CpuTopology
+		os                      *ovirtsdk.OperatingSystem
+		tptags                  = make(map[string]string)
+		tpfields                = make(map[string]interface{})
+		vmcount                 map[string]int
+		latest                  map[string]int64
+		id, name, dcname        string
+		clname, ostype          string
+		vername, baseid         string
+		t                       time.Time
+		mem, cores              int64
+		sockets, threads, vernr int64
+		ok                      bool
+		err                     error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get templates info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllTemplates(ctx); err != nil {
+		return fmt.Errorf("could not get all template entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	if err = c.getAllInstanceTypes(ctx); err != nil {
+		return fmt.Errorf("could not get all instance type entity lists: %w", err)
+	}
+	t = time.Now()
+	vmcount = c.countVMsPerTemplate()
+	latest = c.latestTemplateVersions()
+
+	for _, tp := range c.tps.Slice() {
+		if id, ok = tp.Id(); !ok {
+			acc.AddError(errors.New("found a template without Id, skipping"))
+			continue
+		}
+		if name, ok = tp.Name(); !ok {
+			acc.AddError(errors.New("found a template without Name, skipping"))
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = tp.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		status, _ = tp.Status()
+		vername, vernr, baseid = templateVersion(tp)
+		ostype = ""
+		if os, ok = tp.Os(); ok {
+			ostype, _ = os.Type()
+		}
+		cores, sockets, threads = 0, 0, 0
+		if cpu, ok = tp.Cpu(); ok {
+			if cort, ok = cpu.Topology(); ok {
+				cores, _ = cort.Cores()
+				sockets, _ = cort.Sockets()
+				threads, _ = cort.Threads()
+			}
+		}
+		mem, _ = tp.Memory()
+
+		tptags["base_template_id"] = baseid
+		tptags["clustername"] = clname
+		tptags["dcname"] = dcname
+		tptags["id"] = id
+		tptags["name"] = name
+		tptags["os_type"] = ostype
+		tptags["ovirt-engine"] = c.url.Host
+
+		tpfields["cpu_cores"] = cores
+		tpfields["cpu_sockets"] = sockets
+		tpfields["cpu_threads"] = threads
+		tpfields["latest_version"] = vernr >= latest[baseid]
+		tpfields["memory_size"] = mem
+		tpfields["status"] = string(status)
+		tpfields["status_code"] = templateStatusCode(status)
+		tpfields["version_name"] = vername
+		tpfields["version_number"] = vernr
+		tpfields["vms"] = vmcount[id]
+
+		acc.AddFields("ovirtstat_template", tpfields, tptags, t)
+	}
+	c.addInstanceTypes(acc, t)
+
+	return nil
+}
+
+// addInstanceTypes adds an ovirtstat_instance_type metric per instance type from cache
+func (c *OVirtCollector) addInstanceTypes(acc *metric.Accumulator, t time.Time) {
+	var (
+		it               *ovirtsdk.InstanceType
+		cpu              *ovirtsdk.Cpu
+		cort             *ovirtsdk.CpuTopology
+		ittags           = make(map[string]string)
+		itfields         = make(map[string]interface{})
+		vmcount          = make(map[string]int)
+		id, name         string
+		mem, cores       int64
+		sockets, threads int64
+		ok               bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if it, ok = vm.InstanceType(); ok {
+			if id, ok = it.Id(); ok {
+				vmcount[id]++
+			}
+		}
+	}
+	for _, it = range c.its.Slice() {
+		if id, ok = it.Id(); !ok {
+			acc.AddError(errors.New("found an instance type without Id, skipping"))
+			continue
+		}
+		if name, ok = it.Name(); !ok {
+			acc.AddError(errors.New("found an instance type without Name, skipping"))
+			continue
+		}
+		cores, sockets, threads = 0, 0, 0
+		if cpu, ok = it.Cpu(); ok {
+			if cort, ok = cpu.Topology(); ok {
+				cores, _ = cort.Cores()
+				sockets, _ = cort.Sockets()
+				threads, _ = cort.Threads()
+			}
+		}
+		mem, _ = it.Memory()
+
+		ittags["id"] = id
+		ittags["name"] = name
+		ittags["ovirt-engine"] = c.url.Host
+
+		itfields["cpu_cores"] = cores
+		itfields["cpu_sockets"] = sockets
+		itfields["cpu_threads"] = threads
+		itfields["memory_size"] = mem
+		itfields["vms"] = vmcount[id]
+
+		acc.AddFields("ovirtstat_instance_type", itfields, ittags, t)
+	}
+}
+
+// countVMsPerTemplate returns the number of VMs based on each template Id from cache
+func (c *OVirtCollector) countVMsPerTemplate() map[string]int {
+	var (
+		tp    *ovirtsdk.Template
+		tpid  string
+		count = make(map[string]int)
+		ok    bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if tp, ok = vm.Template(); ok {
+			if tpid, ok = tp.Id(); ok {
+				count[tpid]++
+			}
+		}
+	}
+	return count
+}
+
+// latestTemplateVersions returns the latest version number of each base template Id
+// from cache
+func (c *OVirtCollector) latestTemplateVersions() map[string]int64 {
+	var (
+		latest = make(map[string]int64)
+		baseid string
+		vernr  int64
+	)
+
+	for _, tp := range c.tps.Slice() {
+		_, vernr, baseid = templateVersion(tp)
+		if vernr > latest[baseid] {
+			latest[baseid] = vernr
+		}
+	}
+	return latest
+}
+
+// templateVersion returns the version name, number and base template Id of a template.
+// Base templates are their own base template.
+func templateVersion(tp *ovirtsdk.Template) (string, int64, string) {
+	var (
+		tpver        *ovirtsdk.TemplateVersion
+		base         *ovirtsdk.Template
+		name, baseid string
+		number       int64
+		ok           bool
+	)
+
+	baseid, _ = tp.Id()
+	if tpver, ok = tp.Version(); ok {
+		name, _ = tpver.VersionName()
+		number, _ = tpver.VersionNumber()
+		if base, ok = tpver.BaseTemplate(); ok {
+			baseid, _ = base.Id()
+		}
+	}
+	return name, number, baseid
+}
+
+// templateStatusCode converts TemplateStatus to int16 for easy alerting
+func templateStatusCode(status ovirtsdk.TemplateStatus) int16 {
+	switch status {
+	case ovirtsdk.TEMPLATESTATUS_OK:
+		return 0
+	case ovirtsdk.TEMPLATESTATUS_LOCKED:
+		return 1
+	case ovirtsdk.TEMPLATESTATUS_ILLEGAL:
+		return 2
+	default:
+		return 1
+	}
+}
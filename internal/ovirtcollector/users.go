@@ -0,0 +1,395 @@
+// This file contains ovirtcollector methods to gathers stats about users, sessions and
+// administrative role assignments
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// superUserRole is the name of oVirt's built-in role with all permissions
+const superUserRole = "SuperUser"
+
+// authzDomainUsage contains the number of users and groups of an authorization domain
+type authzDomainUsage struct {
+	users, groups, loggedin int
+}
+
+// CollectUsersInfo gathers oVirt users and groups per authorization domain, VM console
+// sessions and administrative role assignments. Role assignment changes are reported
+// as entity events.
+func (c *OVirtCollector) CollectUsersInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		users    *ovirtsdk.UserSlice
+		groups   *ovirtsdk.GroupSlice
+		domains  map[string]string
+		roles    map[string]*ovirtsdk.Role
+		usage    = make(map[string]*authzDomainUsage)
+		domain   string
+		t        time.Time
+		loggedin bool
+		err      error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get users info: %w", ErrorNoClient)
+	}
+
+	if err = c.getDatacentersAndClusters(ctx); err != nil {
+		return fmt.Errorf("could not get all datacenter entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersHosts(ctx); err != nil {
+		return fmt.Errorf("could not get all hosts entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersStorageDomains(ctx); err != nil {
+		return fmt.Errorf("could not get all storagedomain entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	if err = c.getAllTemplates(ctx); err != nil {
+		return fmt.Errorf("could not get all template entity lists: %w", err)
+	}
+	if err = c.getAllVMPools(ctx); err != nil {
+		return fmt.Errorf("could not get all VM pool entity lists: %w", err)
+	}
+	if domains, err = c.authzDomains(); err != nil {
+		return fmt.Errorf("could not get authorization domain list: %w", err)
+	}
+	if roles, err = c.listRoles(); err != nil {
+		return fmt.Errorf("could not get role list: %w", err)
+	}
+	if users, groups, err = c.listUsersAndGroups(); err != nil {
+		return fmt.Errorf("could not get user and group lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, user := range users.Slice() {
+		domain = domains[entityDomainID(user.Domain)]
+		if _, ok := usage[domain]; !ok {
+			usage[domain] = &authzDomainUsage{}
+		}
+		usage[domain].users++
+		if loggedin, _ = user.LoggedIn(); loggedin {
+			usage[domain].loggedin++
+		}
+		if perms, ok := user.Permissions(); ok {
+			c.addRoleAssignments(acc, perms, roles, "user", userName(user), t)
+		}
+	}
+	for _, group := range groups.Slice() {
+		domain = domains[entityDomainID(group.Domain)]
+		if _, ok := usage[domain]; !ok {
+			usage[domain] = &authzDomainUsage{}
+		}
+		usage[domain].groups++
+		if perms, ok := group.Permissions(); ok {
+			name, _ := group.Name()
+			c.addRoleAssignments(acc, perms, roles, "group", name, t)
+		}
+	}
+	c.raStates.flush(acc, c.url.Host, t)
+
+	for domain, du := range usage {
+		acc.AddFields(
+			"ovirtstat_authz_domain",
+			map[string]interface{}{
+				"groups":          du.groups,
+				"users":           du.users,
+				"users_logged_in": du.loggedin,
+			},
+			map[string]string{
+				"name":         domain,
+				"ovirt-engine": c.url.Host,
+			},
+			t,
+		)
+	}
+
+	if c.vmsFollow("sessions") {
+		c.collectVMSessions(acc, t)
+	}
+
+	return nil
+}
+
+// addRoleAssignments adds an ovirtstat_role_assignment metric per administrative role
+// permission of a user or group
+func (c *OVirtCollector) addRoleAssignments(
+	acc *metric.Accumulator,
+	perms *ovirtsdk.PermissionSlice,
+	roles map[string]*ovirtsdk.Role,
+	ptype, principal string,
+	t time.Time,
+) {
+	var (
+		role              *ovirtsdk.Role
+		id, roleid, rname string
+		otype, oname      string
+		admin, ok         bool
+	)
+
+	for _, perm := range perms.Slice() {
+		if id, ok = perm.Id(); !ok {
+			continue
+		}
+		if role, ok = perm.Role(); !ok {
+			continue
+		}
+		roleid, _ = role.Id()
+		if role, ok = roles[roleid]; !ok {
+			continue
+		}
+		if admin, _ = role.Administrative(); !admin {
+			continue
+		}
+		rname, _ = role.Name()
+		otype, oname = c.permissionObject(perm)
+		c.raStates.see(acc, c.url.Host, id, principal+" "+rname+" "+otype+" "+oname, t)
+
+		acc.AddFields(
+			"ovirtstat_role_assignment",
+			map[string]interface{}{
+				"superuser": rname == superUserRole,
+			},
+			map[string]string{
+				"id":             id,
+				"object_name":    oname,
+				"object_type":    otype,
+				"ovirt-engine":   c.url.Host,
+				"principal":      principal,
+				"principal_type": ptype,
+				"role":           rname,
+			},
+			t,
+		)
+	}
+}
+
+// collectVMSessions adds an ovirtstat_user_session metric per VM session from cache.
+// Sessions do not include their creation time, so their age is counted from the first
+// collection they were seen in.
+func (c *OVirtCollector) collectVMSessions(acc *metric.Accumulator, t time.Time) {
+	var (
+		sessions       *ovirtsdk.SessionSlice
+		user           *ovirtsdk.User
+		ip             *ovirtsdk.Ip
+		seen           = make(map[string]time.Time)
+		first          time.Time
+		vmname, id     string
+		username, addr string
+		protocol       string
+		ok, console    bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if sessions, ok = vm.Sessions(); !ok {
+			continue
+		}
+		vmname, _ = vm.Name()
+		if !c.filterVms.Match(vmname) {
+			continue
+		}
+		for _, se := range sessions.Slice() {
+			if id, ok = se.Id(); !ok {
+				continue
+			}
+			username = ""
+			if user, ok = se.User(); ok {
+				username = userName(user)
+			}
+			addr = ""
+			if ip, ok = se.Ip(); ok {
+				addr, _ = ip.Address()
+			}
+			console, _ = se.ConsoleUser()
+			protocol, _ = se.Protocol()
+			if first, ok = c.sessionsSeen[id]; !ok {
+				first = t
+			}
+			seen[id] = first
+
+			acc.AddFields(
+				"ovirtstat_user_session",
+				map[string]interface{}{
+					"age_seconds":  int64(t.Sub(first).Seconds()),
+					"console_user": console,
+					"ip":           addr,
+					"protocol":     protocol,
+				},
+				map[string]string{
+					"id":           id,
+					"ovirt-engine": c.url.Host,
+					"user":         username,
+					"vmname":       vmname,
+				},
+				t,
+			)
+		}
+	}
+	c.sessionsSeen = seen
+}
+
+// permissionObject returns the type and name of the object a permission applies to
+func (c *OVirtCollector) permissionObject(perm *ovirtsdk.Permission) (string, string) {
+	var id string
+
+	if vm, ok := perm.Vm(); ok {
+		id, _ = vm.Id()
+		return "vm", nameOrID(c.vmNameFromID(id), id)
+	}
+	if ho, ok := perm.Host(); ok {
+		id, _ = ho.Id()
+		return "host", nameOrID(c.hostNameFromID(id), id)
+	}
+	if cl, ok := perm.Cluster(); ok {
+		id, _ = cl.Id()
+		return "cluster", nameOrID(c.clusterName(cl), id)
+	}
+	if sd, ok := perm.StorageDomain(); ok {
+		id, _ = sd.Id()
+		return "storagedomain", nameOrID(c.storageDomainNameFromID(id), id)
+	}
+	if dc, ok := perm.DataCenter(); ok {
+		id, _ = dc.Id()
+		return "datacenter", nameOrID(c.datacenterNameFromID(id), id)
+	}
+	if tp, ok := perm.Template(); ok {
+		id, _ = tp.Id()
+		return "template", nameOrID(c.templateNameFromID(id), id)
+	}
+	if pool, ok := perm.VmPool(); ok {
+		id, _ = pool.Id()
+		return "vm_pool", nameOrID(c.vmPoolNameFromID(id), id)
+	}
+	if disk, ok := perm.Disk(); ok {
+		id, _ = disk.Id()
+		return "disk", id
+	}
+	return "system", ""
+}
+
+// authzDomains returns the name of each authorization domain Id
+func (c *OVirtCollector) authzDomains() (map[string]string, error) {
+	var (
+		resp     *ovirtsdk.DomainsServiceListResponse
+		domslice *ovirtsdk.DomainSlice
+		domains  = make(map[string]string)
+		id, name string
+		ok       bool
+		err      error
+	)
+
+	if resp, err = c.conn.SystemService().DomainsService().List().Send(); err != nil {
+		return nil, err
+	}
+	if domslice, ok = resp.Domains(); !ok {
+		return domains, nil
+	}
+	for _, dom := range domslice.Slice() {
+		if id, ok = dom.Id(); !ok {
+			continue
+		}
+		name, _ = dom.Name()
+		domains[id] = name
+	}
+	return domains, nil
+}
+
+// listRoles returns each role by its Id
+func (c *OVirtCollector) listRoles() (map[string]*ovirtsdk.Role, error) {
+	var (
+		resp  *ovirtsdk.RolesServiceListResponse
+		slice *ovirtsdk.RoleSlice
+		roles = make(map[string]*ovirtsdk.Role)
+		id    string
+		ok    bool
+		err   error
+	)
+
+	if resp, err = c.conn.SystemService().RolesService().List().Send(); err != nil {
+		return nil, err
+	}
+	if slice, ok = resp.Roles(); !ok {
+		return roles, nil
+	}
+	for _, role := range slice.Slice() {
+		if id, ok = role.Id(); ok {
+			roles[id] = role
+		}
+	}
+	return roles, nil
+}
+
+// listUsersAndGroups returns all users and groups including their permissions
+func (c *OVirtCollector) listUsersAndGroups() (*ovirtsdk.UserSlice, *ovirtsdk.GroupSlice, error) {
+	var (
+		uresp  *ovirtsdk.UsersServiceListResponse
+		gresp  *ovirtsdk.GroupsServiceListResponse
+		users  *ovirtsdk.UserSlice
+		groups *ovirtsdk.GroupSlice
+		ok     bool
+		err    error
+	)
+
+	uresp, err = c.conn.SystemService().UsersService().List().Follow("permissions").Send()
+	if err != nil {
+		return nil, nil, err
+	}
+	if users, ok = uresp.Users(); !ok {
+		users = &ovirtsdk.UserSlice{}
+	}
+	gresp, err = c.conn.SystemService().GroupsService().List().Follow("permissions").Send()
+	if err != nil {
+		return nil, nil, err
+	}
+	if groups, ok = gresp.Groups(); !ok {
+		groups = &ovirtsdk.GroupSlice{}
+	}
+	return users, groups, nil
+}
+
+// entityDomainID returns the Id of the authorization domain returned by domain func
+func entityDomainID(domain func() (*ovirtsdk.Domain, bool)) string {
+	if dom, ok := domain(); ok {
+		id, _ := dom.Id()
+		return id
+	}
+	return ""
+}
+
+// userName returns the user name of a user, or its name or Id if not available
+func userName(user *ovirtsdk.User) string {
+	var (
+		name string
+		ok   bool
+	)
+
+	if name, ok = user.UserName(); ok {
+		return name
+	}
+	if name, ok = user.Name(); ok {
+		return name
+	}
+	name, _ = user.Id()
+	return name
+}
+
+// nameOrID returns name if it is not empty or id otherwise
+func nameOrID(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
@@ -0,0 +1,167 @@
+// This file contains ovirtcollector methods to gathers stats about VMs guest info
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// CollectVMGuestInfo gathers oVirt VMs guest info reported by their guest agent
+func (c *OVirtCollector) CollectVMGuestInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		status                   ovirtsdk.VmStatus
+		cl                       *ovirtsdk.Cluster
+		ho                       *ovirtsdk.Host
+		gos                      *ovirtsdk.GuestOperatingSystem
+		tz                       *ovirtsdk.TimeZone
+		ver                      *ovirtsdk.Version
+		kern                     *ovirtsdk.Kernel
+		rds                      *ovirtsdk.ReportedDeviceSlice
+		vmtags                   = make(map[string]string)
+		vmfields                 = make(map[string]interface{})
+		id, name, dcname         string
+		clname, hostname         string
+		arch, family, distro     string
+		osversion, kernel        string
+		fqdn, tzname, tzoffset   string
+		ipv4                     string
+		t                        time.Time
+		ips                      int
+		ok, agent, nextrunconfig bool
+		err                      error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get VMs guest info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	t = time.Now()
+
+	for _, vm := range c.vms.Slice() {
+		if id, ok = vm.Id(); !ok {
+			acc.AddError(errors.New("found a VM without Id, skipping"))
+			continue
+		}
+		if name, ok = vm.Name(); !ok {
+			acc.AddError(errors.New("found a VM without Name, skipping"))
+			continue
+		}
+		if !c.filterVms.Match(name) {
+			continue
+		}
+		hostname = ""
+		if ho, ok = vm.Host(); ok {
+			hostname = c.hostName(ho)
+			if !c.filterHosts.Match(hostname) {
+				continue
+			}
+		}
+		clname, dcname = "", ""
+		if cl, ok = vm.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		status, _ = vm.Status()
+		agent = false
+		arch, family, distro, osversion, kernel = "", "", "", "", ""
+		if gos, ok = vm.GuestOperatingSystem(); ok {
+			agent = true
+			arch, _ = gos.Architecture()
+			family, _ = gos.Family()
+			distro, _ = gos.Distribution()
+			if ver, ok = gos.Version(); ok {
+				osversion, _ = ver.FullVersion()
+			}
+			if kern, ok = gos.Kernel(); ok {
+				if ver, ok = kern.Version(); ok {
+					kernel, _ = ver.FullVersion()
+				}
+			}
+		}
+		tzname, tzoffset = "", ""
+		if tz, ok = vm.GuestTimeZone(); ok {
+			tzname, _ = tz.Name()
+			tzoffset, _ = tz.UtcOffset()
+		}
+		if fqdn, ok = vm.Fqdn(); ok && fqdn != "" {
+			agent = true
+		}
+		ips, ipv4 = 0, ""
+		if rds, ok = vm.ReportedDevices(); ok {
+			ips, ipv4 = reportedDevicesIPs(rds)
+		}
+		agent = agent || ips > 0
+		nextrunconfig, _ = vm.NextRunConfigurationExists()
+
+		vmtags["clustername"] = clname
+		vmtags["dcname"] = dcname
+		vmtags["hostname"] = hostname
+		vmtags["id"] = id
+		vmtags["name"] = name
+		vmtags["os_architecture"] = arch
+		vmtags["os_distribution"] = distro
+		vmtags["os_family"] = family
+		vmtags["os_kernel"] = kernel
+		vmtags["os_version"] = osversion
+		vmtags["ovirt-engine"] = c.url.Host
+
+		vmfields["fqdn"] = fqdn
+		vmfields["guest_agent"] = agent
+		vmfields["ip_count"] = ips
+		vmfields["ipv4"] = ipv4
+		vmfields["next_run_configuration_exists"] = nextrunconfig
+		vmfields["status"] = string(status)
+		vmfields["timezone"] = tzname
+		vmfields["utc_offset"] = tzoffset
+
+		acc.AddFields("ovirtstat_vm_guest", vmfields, vmtags, t)
+	}
+
+	return nil
+}
+
+// reportedDevicesIPs returns the number of IP addresses reported by the guest agent
+// and the first IPv4 address found
+func reportedDevicesIPs(rds *ovirtsdk.ReportedDeviceSlice) (int, string) {
+	var (
+		ipver         ovirtsdk.IpVersion
+		ipsl          *ovirtsdk.IpSlice
+		address, ipv4 string
+		count         int
+		ok            bool
+	)
+
+	for _, rd := range rds.Slice() {
+		if ipsl, ok = rd.Ips(); !ok {
+			continue
+		}
+		for _, ip := range ipsl.Slice() {
+			if address, ok = ip.Address(); !ok || address == "" {
+				continue
+			}
+			count++
+			if ipver, ok = ip.Version(); ok && ipver == ovirtsdk.IPVERSION_V4 && ipv4 == "" {
+				ipv4 = address
+			}
+		}
+	}
+	return count, ipv4
+}
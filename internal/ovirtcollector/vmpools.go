@@ -0,0 +1,134 @@
+// This file contains ovirtcollector methods to gathers stats about VM pools
+//
+// Author: Tesifonte Belda
+// License: The MIT License (MIT)
+
+package ovirtcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	"github.com/tesibelda/lightmetric/metric"
+)
+
+// vmPoolUsage contains the number of VMs of a pool per status
+type vmPoolUsage struct {
+	total, up, down int
+}
+
+// CollectVMPoolsInfo gathers oVirt VM pools info
+func (c *OVirtCollector) CollectVMPoolsInfo(
+	ctx context.Context,
+	acc *metric.Accumulator,
+) error {
+	var (
+		ptype            ovirtsdk.VmPoolType
+		cl               *ovirtsdk.Cluster
+		potags           = make(map[string]string)
+		pofields         = make(map[string]interface{})
+		usage            map[string]*vmPoolUsage
+		vmsusage         *vmPoolUsage
+		id, name, dcname string
+		clname           string
+		t                time.Time
+		size, prestarted int64
+		maxvms           int64
+		ok, stateful     bool
+		err              error
+	)
+
+	if c.conn == nil {
+		return fmt.Errorf("could not get VM pools info: %w", ErrorNoClient)
+	}
+
+	if err = c.getAllVMPools(ctx); err != nil {
+		return fmt.Errorf("could not get all VM pool entity lists: %w", err)
+	}
+	if err = c.getAllDatacentersVMs(ctx); err != nil {
+		return fmt.Errorf("could not get all VM entity lists: %w", err)
+	}
+	t = time.Now()
+	usage = c.vmPoolsUsage()
+
+	for _, pool := range c.pools.Slice() {
+		if id, ok = pool.Id(); !ok {
+			acc.AddError(errors.New("found a VM pool without Id, skipping"))
+			continue
+		}
+		if name, ok = pool.Name(); !ok {
+			acc.AddError(errors.New("found a VM pool without Name, skipping"))
+			continue
+		}
+		clname, dcname = "", ""
+		if cl, ok = pool.Cluster(); ok {
+			clname = c.clusterName(cl)
+			if !c.filterClusters.Match(clname) {
+				continue
+			}
+			dcname = c.clusterDatacenterName(cl)
+		}
+		ptype, _ = pool.Type()
+		size, _ = pool.Size()
+		prestarted, _ = pool.PrestartedVms()
+		maxvms, _ = pool.MaxUserVms()
+		stateful, _ = pool.Stateful()
+		if vmsusage, ok = usage[id]; !ok {
+			vmsusage = &vmPoolUsage{}
+		}
+
+		potags["clustername"] = clname
+		potags["dcname"] = dcname
+		potags["id"] = id
+		potags["name"] = name
+		potags["ovirt-engine"] = c.url.Host
+		potags["type"] = string(ptype)
+
+		pofields["max_user_vms"] = maxvms
+		pofields["prestarted_vms"] = prestarted
+		pofields["size"] = size
+		pofields["stateful"] = stateful
+		pofields["vms"] = vmsusage.total
+		pofields["vms_down"] = vmsusage.down
+		pofields["vms_up"] = vmsusage.up
+
+		acc.AddFields("ovirtstat_vm_pool", pofields, potags, t)
+	}
+
+	return nil
+}
+
+// vmPoolsUsage returns the number of VMs per status of each VM pool Id from cache
+func (c *OVirtCollector) vmPoolsUsage() map[string]*vmPoolUsage {
+	var (
+		status ovirtsdk.VmStatus
+		pool   *ovirtsdk.VmPool
+		usage  = make(map[string]*vmPoolUsage)
+		poolid string
+		ok     bool
+	)
+
+	for _, vm := range c.vms.Slice() {
+		if pool, ok = vm.VmPool(); !ok {
+			continue
+		}
+		if poolid, ok = pool.Id(); !ok {
+			continue
+		}
+		if _, ok = usage[poolid]; !ok {
+			usage[poolid] = &vmPoolUsage{}
+		}
+		usage[poolid].total++
+		status, _ = vm.Status()
+		switch status {
+		case ovirtsdk.VMSTATUS_UP:
+			usage[poolid].up++
+		case ovirtsdk.VMSTATUS_DOWN:
+			usage[poolid].down++
+		}
+	}
+	return usage
+}
@@ -33,6 +33,8 @@ func (c *OVirtCollector) CollectVmsInfo(
 		clname, hostname string
 		t                time.Time
 		mem, cores       int64
+		oldestsnap       int64
+		snaps            int
 		sockets, threads int64
 		ok, stateless    bool
 		runOnce          bool
@@ -60,10 +62,9 @@ func (c *OVirtCollector) CollectVmsInfo(
 		if !c.filterVms.Match(name) {
 			continue
 		}
-		if status, ok = vm.Status(); !ok {
-			acc.AddError(fmt.Errorf("could not get status for VM %s", name))
-			continue
-		}
+		// track VMs before host and cluster filters so migrating them does not emit events
+		c.vmStates.see(acc, c.url.Host, id, name, t)
+		hostname = ""
 		if ho, ok = vm.Host(); ok {
 			hostname = c.hostName(ho)
 			if !c.filterHosts.Match(hostname) {
@@ -79,6 +80,10 @@ func (c *OVirtCollector) CollectVmsInfo(
 			}
 			dcname = c.clusterDatacenterName(cl)
 		}
+		if status, ok = vm.Status(); !ok {
+			acc.AddError(fmt.Errorf("could not get status for VM %s", name))
+			continue
+		}
 		cores, sockets, threads = 0, 0, 0
 		if cpu, ok = vm.Cpu(); ok {
 			if cort, ok = cpu.Topology(); ok {
@@ -98,6 +103,9 @@ func (c *OVirtCollector) CollectVmsInfo(
 		vmtags["name"] = name
 		vmtags["ovirt-engine"] = c.url.Host
 		vmtags["type"] = string(vtype)
+		if c.vmNumaPins != nil {
+			vmtags["numa_pinned_nodes"] = c.vmNumaPins[id]
+		}
 
 		vmfields["cpu_cores"] = cores
 		vmfields["cpu_sockets"] = sockets
@@ -105,11 +113,18 @@ func (c *OVirtCollector) CollectVmsInfo(
 		vmfields["memory_size"] = mem
 		vmfields["run_once"] = runOnce
 		vmfields["stateless"] = stateless
+		if c.vmsFollow("snapshots.disks") {
+			snaps, oldestsnap = vmSnapshotsSummary(vm, t)
+			vmfields["oldest_snapshot_age"] = oldestsnap
+			vmfields["snapshot_count"] = snaps
+		}
 		vmfields["status"] = string(status)
 		vmfields["status_code"] = vmStatusCode(status)
 
 		acc.AddFields("ovirtstat_vm", vmfields, vmtags, t)
+		c.vmStates.update(acc, c.url.Host, id, name, string(status), vmStatusCode(status), t)
 	}
+	c.vmStates.flush(acc, c.url.Host, t)
 
 	return err
 }
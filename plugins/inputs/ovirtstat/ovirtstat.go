@@ -30,17 +30,31 @@ type Config struct {
 	Timeout       time.Duration `toml:"timeout"`
 	InternalAlias string        `toml:"internal_alias"`
 
-	ClustersExclude []string `toml:"clusters_exclude"`
-	ClustersInclude []string `toml:"clusters_include"`
-	HostsExclude    []string `toml:"hosts_exclude"`
-	HostsInclude    []string `toml:"hosts_include"`
-	VmsExclude      []string `toml:"vms_exclude"`
-	VmsInclude      []string `toml:"vms_include"`
-
-	CollectorsExclude []string `toml:"collectors_exclude"`
-	CollectorsInclude []string `toml:"collectors_include"`
-	collectors        map[string]bool
-	filterCollectors  filter.Filter
+	DatacentersExclude []string `toml:"datacenters_exclude"`
+	DatacentersInclude []string `toml:"datacenters_include"`
+	ClustersExclude    []string `toml:"clusters_exclude"`
+	ClustersInclude    []string `toml:"clusters_include"`
+	HostsExclude       []string `toml:"hosts_exclude"`
+	HostsInclude       []string `toml:"hosts_include"`
+	VmsExclude         []string `toml:"vms_exclude"`
+	VmsInclude         []string `toml:"vms_include"`
+
+	HostsCertificateCheck bool `toml:"hosts_certificate_check"`
+	EnginePKICACheck      bool `toml:"engine_pki_ca_check"`
+
+	FenceStatus    bool `toml:"fence_status"`
+	PMAgentAddress bool `toml:"pm_agent_address"`
+
+	GlusterVolumeStatistics bool `toml:"gluster_volume_statistics"`
+
+	ForecastStateFile string        `toml:"forecast_state_file"`
+	ForecastWindow    time.Duration `toml:"forecast_window"`
+
+	CollectorsExclude  []string                 `toml:"collectors_exclude"`
+	CollectorsInclude  []string                 `toml:"collectors_include"`
+	CollectorsInterval map[string]time.Duration `toml:"collectors_interval"`
+	collectors         map[string]bool
+	filterCollectors   filter.Filter
 
 	version      string
 	pollInterval time.Duration
@@ -65,6 +79,12 @@ timeout = "10s"
 ## optional alias tag for internal metrics
 # internal_alias = ""
 
+## Filter datacenters by name, default is no filtering
+## used by Datacenters and Quotas collectors
+## datacenter names can be specified as glob patterns
+# datacenters_include = []
+# datacenters_exclude = []
+
 ## Filter clusters by name, default is no filtering
 ## cluster names can be specified as glob patterns
 # clusters_include = []
@@ -80,16 +100,79 @@ timeout = "10s"
 # vms_include = []
 # vms_exclude = []
 
-## Filter collectors by name, default is all collectors
+## Certificates collector options
+## check also the engine PKI CA certificate downloaded from its pki-resource endpoint
+# engine_pki_ca_check = false
+
+## Hosts collector options
+## check hosts VDSM certificate expiry connecting to each host VDSM port
+# hosts_certificate_check = false
+
+## HostPowerManagement collector options
+## fence agents are listed per host and refreshed with the Hosts collectors_interval
+## check hosts power management status with the fence status action (it is an action call)
+# fence_status = false
+## report fence agents addresses
+# pm_agent_address = false
+
+## GlusterVolumes collector options
+## gather gluster volumes statistics (one more API call per volume)
+# gluster_volume_statistics = false
+
+## Capacity forecasting of Clusters and StorageDomains collectors, disabled by default
+## keeps a rolling window of cluster committed memory and storagedomain used bytes in
+## this state file and adds growth_per_day and days_until_full fields from its trend
+# forecast_state_file = "/var/lib/telegraf/ovirtstat_forecast.json"
+## rolling window of samples used to fit the trend, default is 7 days
+# forecast_window = "168h"
+
+## Filter collectors by name, default is all collectors except opt-in ones,
+## which are only used if they are in collectors_include
 ## see possible collector names bellow
 # collectors_include = []
 # collectors_exclude = []
 
+## Refresh interval of cached entities per collector, default is the poll interval.
+## Collectors whose interval has not elapsed yet emit their last known values.
+## GlusterVolumes collector uses the Datacenters interval
+# collectors_interval = {Datacenters = "10m", StorageDomains = "5m", VMs = "1m"}
+
 #### collector names available are ####
+## AffinityGroups: affinity group stats and rule violations in ovirtstat_affinity_group and
+##  ovirtstat_affinity_violation measurements
+## Backups (opt-in): image transfers, VM backups and checkpoints in ovirtstat_image_transfer,
+##  ovirtstat_vm_backup and ovirtstat_vm_checkpoints measurements
+## Certificates: engine certificate chain in ovirtstat_certificate measurement
+## Clusters (opt-in): cluster scheduling policy and balance indicators computed from its
+##  hosts in ovirtstat_cluster measurement
 ## Datacenters: datacenter stats in ovirtstat_datacenter measurement
+## GlusterBricks: gluster brick stats in ovirtstat_gluster_brick measurement
 ## GlusterVolumes: gluster volume stats in ovirtstat_glustervolume measurement
+## HostDevices (opt-in): host PCI, USB and SCSI devices and mediated device types in
+##  ovirtstat_host_device and ovirtstat_host_mdev_type measurements
+## HostedEngine (opt-in): hosted engine stats in ovirtstat_hosted_engine measurements
+## HostNuma (opt-in): host NUMA nodes and hugepages in ovirtstat_host_numa_node and
+##  ovirtstat_host_hugepages measurements, adds numa_pinned_nodes tag to ovirtstat_vm
+## HostPowerManagement: host power management and SPM stats in ovirtstat_host_power_management
 ## Hosts: hypervisor/host stats in ovirtstat_host measurement
+## Jobs: engine jobs stats in ovirtstat_jobs, ovirtstat_job and ovirtstat_job_failure
+##  measurements
+## Networks: logical network stats in ovirtstat_network, ovirtstat_cluster_network and
+##  ovirtstat_vnic_profile measurements
+## Quotas: datacenter quota limits and usage in ovirtstat_quota measurement
+## Snapshots: VM snapshot stats in ovirtstat_vm_snapshot measurement
+## StorageConnections (opt-in): storage connections, host iSCSI sessions and LUN paths in
+##  ovirtstat_storage_connection, ovirtstat_host_iscsi and ovirtstat_host_lun measurements
+## StorageDomainDisks (opt-in): storagedomain disks inventory in ovirtstat_storagedomain_disks
+##  and ovirtstat_disk_illegal measurements
 ## StorageDomains: cluster stats in ovirtstat_storagedomains measurement
+## Templates: template and instance type stats in ovirtstat_template and
+##  ovirtstat_instance_type measurements
+## Users (opt-in): users and groups per authorization domain, administrative role
+##  assignments and VM console sessions in ovirtstat_authz_domain,
+##  ovirtstat_role_assignment and ovirtstat_user_session measurements
+## VMGuestInfo: virtual machine guest agent info in ovirtstat_vm_guest measurement
+## VMPools: VM pool stats in ovirtstat_vm_pool measurement
 ## VMs: virtual machine stats in ovirtstat_vm measurement
 `
 
@@ -120,10 +203,11 @@ func (c *Config) LoadConfig(filename string) error {
 // Start initializes internal ovirtstat variables with the provided configuration
 func (c *Config) Start() error {
 	var (
-		tags map[string]string
-		u    *url.URL
-		t    time.Time
-		err  error
+		tags  map[string]string
+		u     *url.URL
+		t     time.Time
+		err   error
+		exist bool
 	)
 
 	if c.ovc != nil {
@@ -140,7 +224,23 @@ func (c *Config) Start() error {
 	}
 
 	/// Set ovirtcollector options
-	c.ovc.SetDataDuration(time.Duration(c.pollInterval.Seconds() * 0.9))
+	c.ovc.SetDataDuration(cacheDuration(c.pollInterval))
+	for coll, du := range c.CollectorsInterval {
+		if err = c.ovc.SetCollectorDataDuration(coll, cacheDuration(du)); err != nil {
+			return fmt.Errorf("error parsing collectors interval: %w", err)
+		}
+	}
+	c.ovc.SetHostsCertificateCheck(c.HostsCertificateCheck)
+	c.ovc.SetFenceStatus(c.FenceStatus)
+	c.ovc.SetPMAgentAddress(c.PMAgentAddress)
+	c.ovc.SetGlusterVolumeStatistics(c.GlusterVolumeStatistics)
+	if err = c.ovc.SetForecast(c.ForecastStateFile, c.ForecastWindow); err != nil {
+		return fmt.Errorf("error loading forecast state file: %w", err)
+	}
+	err = c.ovc.SetFilterDatacenters(c.DatacentersInclude, c.DatacentersExclude)
+	if err != nil {
+		return fmt.Errorf("error parsing datacenters filters: %w", err)
+	}
 	if err = c.ovc.SetFilterClusters(c.ClustersInclude, c.ClustersExclude); err != nil {
 		return fmt.Errorf("error parsing clusters filters: %w", err)
 	}
@@ -153,6 +253,27 @@ func (c *Config) Start() error {
 	if err = c.setFilterCollectors(c.CollectorsInclude, c.CollectorsExclude); err != nil {
 		return fmt.Errorf("error parsing collectors filters: %w", err)
 	}
+	_, exist = c.collectors["HostedEngine"]
+	c.ovc.SetHostsAllContent(exist)
+	_, exist = c.collectors["Certificates"]
+	c.ovc.SetEngineCertificates(exist, c.EnginePKICACheck)
+	if _, exist = c.collectors["VMGuestInfo"]; exist {
+		c.ovc.AddVmsFollow("reported_devices")
+	}
+	if _, exist = c.collectors["Snapshots"]; exist {
+		// snapshot disks are followed so that they are cached with the VM list
+		c.ovc.AddVmsFollow("snapshots.disks")
+	}
+	if _, exist = c.collectors["StorageDomainDisks"]; exist {
+		c.ovc.AddVmsFollow("disk_attachments")
+		c.ovc.AddTemplatesFollow("disk_attachments")
+	}
+	if _, exist = c.collectors["Users"]; exist {
+		c.ovc.AddVmsFollow("sessions")
+	}
+	if _, exist = c.collectors["Clusters"]; exist {
+		c.ovc.AddHostsFollow("statistics")
+	}
 
 	// check OVirt URL
 	if u, err = url.Parse(c.OVirtURL); err != nil {
@@ -294,11 +415,37 @@ func (c *Config) gatherHighLevelEntities(
 		return fmt.Errorf("could not to get API summary from %s: %w", c.OVirtURL, err)
 	}
 
+	//--- Get engine certificates info
+	if _, exist = c.collectors["Certificates"]; exist {
+		err = col.CollectCertificatesInfo(ctx, acc)
+	}
+
 	//--- Get Datacenters info
 	if _, exist = c.collectors["Datacenters"]; exist {
 		err = col.CollectDatacenterInfo(ctx, acc)
 	}
 
+	//--- Get Networks info
+	if _, exist = c.collectors["Networks"]; exist {
+		err = col.CollectNetworksInfo(ctx, acc)
+	}
+
+	//--- Get Quotas info
+	if _, exist = c.collectors["Quotas"]; exist {
+		err = col.CollectQuotasInfo(ctx, acc)
+	}
+
+	//--- Get Jobs info
+	if _, exist = c.collectors["Jobs"]; exist {
+		err = col.CollectJobsInfo(ctx, acc)
+	}
+
+	//--- Get Users info
+	if _, exist = c.collectors["Users"]; exist {
+		// an account without permission to list users must not stop the gather
+		acc.AddError(col.CollectUsersInfo(ctx, acc))
+	}
+
 	return err
 }
 
@@ -317,6 +464,21 @@ func (c *Config) gatherHost(
 	if _, exist = c.collectors["Hosts"]; exist {
 		err = col.CollectHostInfo(ctx, acc)
 	}
+	if _, exist = c.collectors["HostedEngine"]; exist {
+		err = col.CollectHostedEngineInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["HostPowerManagement"]; exist {
+		err = col.CollectHostPowerManagementInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["HostNuma"]; exist {
+		err = col.CollectHostNumaInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["HostDevices"]; exist {
+		err = col.CollectHostDevicesInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["Clusters"]; exist {
+		err = col.CollectClustersInfo(ctx, acc)
+	}
 
 	return err
 }
@@ -339,6 +501,15 @@ func (c *Config) gatherStorage(
 	if _, exist = c.collectors["GlusterVolumes"]; exist {
 		err = col.CollectGlusterVolumeInfo(ctx, acc)
 	}
+	if _, exist = c.collectors["GlusterBricks"]; exist {
+		err = col.CollectGlusterBrickInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["StorageDomainDisks"]; exist {
+		err = col.CollectStorageDomainDisksInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["StorageConnections"]; exist {
+		err = col.CollectStorageConnectionsInfo(ctx, acc)
+	}
 
 	return err
 }
@@ -355,13 +526,57 @@ func (c *Config) gatherVM(ctx context.Context, acc *metric.Accumulator) error {
 	if _, exist = c.collectors["VMs"]; exist {
 		err = col.CollectVmsInfo(ctx, acc)
 	}
+	if _, exist = c.collectors["VMGuestInfo"]; exist {
+		err = col.CollectVMGuestInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["VMPools"]; exist {
+		err = col.CollectVMPoolsInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["Snapshots"]; exist {
+		err = col.CollectSnapshotsInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["Templates"]; exist {
+		err = col.CollectTemplatesInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["AffinityGroups"]; exist {
+		err = col.CollectAffinityGroupsInfo(ctx, acc)
+	}
+	if _, exist = c.collectors["Backups"]; exist {
+		err = col.CollectBackupsInfo(ctx, acc)
+	}
 
 	return err
 }
 
-// setFilterCollectors sets collectors to use given the include and exclude filters
+// setFilterCollectors sets collectors to use given the include and exclude filters.
+// Opt-in collectors are only used if they are matched by the include filter.
 func (c *Config) setFilterCollectors(include, exclude []string) error {
-	var allcollectors = []string{"Datacenters", "GlusterVolumes", "Hosts", "StorageDomains", "VMs"}
+	var allcollectors = []string{
+		"AffinityGroups",
+		"Backups",
+		"Certificates",
+		"Clusters",
+		"Datacenters",
+		"GlusterBricks",
+		"GlusterVolumes",
+		"HostDevices",
+		"HostedEngine",
+		"HostNuma",
+		"HostPowerManagement",
+		"Hosts",
+		"Jobs",
+		"Networks",
+		"Quotas",
+		"Snapshots",
+		"StorageConnections",
+		"StorageDomainDisks",
+		"StorageDomains",
+		"Templates",
+		"Users",
+		"VMGuestInfo",
+		"VMPools",
+		"VMs",
+	}
 	var err error
 
 	c.filterCollectors, err = filter.NewIncludeExcludeFilter(include, exclude)
@@ -372,14 +587,39 @@ func (c *Config) setFilterCollectors(include, exclude []string) error {
 		c.collectors = make(map[string]bool)
 	}
 	for _, coll := range allcollectors {
-		if c.filterCollectors.Match(coll) {
-			c.collectors[coll] = true
+		if !c.filterCollectors.Match(coll) {
+			continue
+		}
+		if len(include) == 0 && isOptInCollector(coll) {
+			continue
 		}
+		c.collectors[coll] = true
 	}
 
 	return nil
 }
 
+// isOptInCollector returns true if the collector should be explicitly included to be used
+func isOptInCollector(coll string) bool {
+	var optincollectors = []string{
+		"Backups",
+		"Clusters",
+		"HostDevices",
+		"HostedEngine",
+		"HostNuma",
+		"StorageConnections",
+		"StorageDomainDisks",
+		"Users",
+	}
+
+	for _, optin := range optincollectors {
+		if coll == optin {
+			return true
+		}
+	}
+	return false
+}
+
 // gatherError adds the error to the metric accumulator
 func gatherError(ctx context.Context, err error) error {
 	// No need to signal errors if we were merely canceled.
@@ -389,6 +629,12 @@ func gatherError(ctx context.Context, err error) error {
 	return err
 }
 
+// cacheDuration returns the max cache data duration for the given refresh interval so
+// that data is refreshed in the gather call where the interval elapses
+func cacheDuration(interval time.Duration) time.Duration {
+	return interval * 9 / 10
+}
+
 // intervalPrecision returns the rounding precision for metrics
 func intervalPrecision(interval time.Duration) time.Duration {
 	switch {